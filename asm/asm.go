@@ -0,0 +1,516 @@
+// Package asm assembles Intel 8080 source code into machine code.
+//
+// It is a two-pass assembler accepting the standard Intel mnemonics with
+// labels, ORG, DB/DW/DS, EQU/SET, IF/ELSE/ENDIF, END and expressions
+// (including the $ location counter).
+package asm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error - assembly error at source line
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("asm: line %d: %s", e.Line, e.Message)
+}
+
+// Line - assembled source line for the listing
+type Line struct {
+	Number  int
+	Address int // -1 when the line has no address
+	Value   int // EQU or SET value, -1 otherwise
+	Bytes   []byte
+	Source  string
+}
+
+// Segment - contiguous assembled bytes
+type Segment struct {
+	Address uint16
+	Data    []byte
+}
+
+// Program - result of the assembly
+type Program struct {
+	// lowest address of the assembled bytes
+	Origin uint16
+	// start address from END, Origin when not specified
+	Entry    uint16
+	Segments []Segment
+	Lines    []Line
+	Symbols  map[string]uint16
+}
+
+type symbol struct {
+	value int
+	isSet bool // redefinable by SET
+}
+
+type assembler struct {
+	pass       int
+	pc         int
+	start      int // location counter at the start of the line, for $
+	lineNumber int
+	symbols    map[string]*symbol
+	conditions []bool
+	ended      bool
+	errs       []error
+
+	program *Program
+	line    *Line
+}
+
+// Assemble - assemble source code, all errors are reported joined
+func Assemble(src string) (*Program, error) {
+	a := &assembler{
+		symbols: map[string]*symbol{},
+		program: &Program{},
+	}
+
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	entry := -1
+	for a.pass = 1; a.pass <= 2; a.pass++ {
+		a.pc = 0
+		a.conditions = nil
+		a.ended = false
+		for i, text := range lines {
+			if a.ended {
+				break
+			}
+			a.lineNumber = i + 1
+			if start := a.assembleLine(text); start >= 0 {
+				entry = start
+			}
+		}
+		if len(a.conditions) > 0 {
+			a.error("missing ENDIF")
+		}
+		if len(a.errs) > 0 {
+			return nil, errors.Join(a.errs...)
+		}
+	}
+
+	p := a.program
+	if len(p.Segments) > 0 {
+		p.Origin = p.Segments[0].Address
+		for _, seg := range p.Segments {
+			if seg.Address < p.Origin {
+				p.Origin = seg.Address
+			}
+		}
+	}
+	p.Entry = p.Origin
+	if entry >= 0 {
+		p.Entry = uint16(entry)
+	}
+	p.Symbols = map[string]uint16{}
+	for name, sym := range a.symbols {
+		p.Symbols[name] = uint16(sym.value)
+	}
+	return p, nil
+}
+
+func (a *assembler) error(format string, args ...interface{}) {
+	a.errs = append(a.errs, &Error{Line: a.lineNumber, Message: fmt.Sprintf(format, args...)})
+}
+
+// assemble one source line, returns the END start address or -1
+func (a *assembler) assembleLine(text string) int {
+	a.start = a.pc
+	a.line = &Line{Number: a.lineNumber, Address: -1, Value: -1, Source: text}
+	if a.pass == 2 {
+		defer func(line *Line) {
+			a.program.Lines = append(a.program.Lines, *line)
+		}(a.line)
+	}
+
+	label, op, operands := splitLine(text)
+	op = strings.ToUpper(op)
+
+	// conditional assembly
+	switch op {
+	case "IF":
+		if !a.active() {
+			a.conditions = append(a.conditions, false)
+			return -1
+		}
+		a.conditions = append(a.conditions, a.definedValue(operands) != 0)
+		return -1
+	case "ELSE":
+		if len(a.conditions) == 0 {
+			a.error("ELSE without IF")
+			return -1
+		}
+		top := len(a.conditions) - 1
+		parent := true
+		for _, c := range a.conditions[:top] {
+			parent = parent && c
+		}
+		a.conditions[top] = parent && !a.conditions[top]
+		return -1
+	case "ENDIF":
+		if len(a.conditions) == 0 {
+			a.error("ENDIF without IF")
+			return -1
+		}
+		a.conditions = a.conditions[:len(a.conditions)-1]
+		return -1
+	}
+	if !a.active() {
+		return -1
+	}
+
+	switch op {
+	case "EQU", "SET", "DEFL", "=":
+		if label == "" {
+			a.error("%s without name", op)
+			return -1
+		}
+		value, ok := a.evaluate(operands)
+		if ok {
+			a.define(label, value, op != "EQU")
+			a.line.Value = value & 0xFFFF
+		}
+		return -1
+	}
+
+	if label != "" {
+		a.define(label, a.pc, false)
+		a.line.Address = a.pc
+	}
+
+	switch op {
+	case "":
+	case "ORG":
+		a.pc = a.definedValue(operands) & 0xFFFF
+		a.line.Address = a.pc
+	case "DB", "DEFB":
+		a.line.Address = a.pc
+		for _, item := range splitOperands(operands) {
+			if s, ok := quotedString(item); ok {
+				a.emit([]byte(s)...)
+				continue
+			}
+			a.emit(a.byteValue(item))
+		}
+	case "DW", "DEFW":
+		a.line.Address = a.pc
+		for _, item := range splitOperands(operands) {
+			value := a.wordValue(item)
+			a.emit(byte(value), byte(value>>8))
+		}
+	case "DS", "DEFS":
+		a.line.Address = a.pc
+		a.pc = (a.pc + a.definedValue(operands)) & 0xFFFF
+	case "END":
+		a.ended = true
+		if strings.TrimSpace(operands) != "" {
+			return int(a.wordValue(operands))
+		}
+	default:
+		enc, ok := instructions[op]
+		if !ok {
+			a.error("unknown instruction %s", op)
+			return -1
+		}
+		a.line.Address = a.pc
+		a.instruction(enc, splitOperands(operands))
+	}
+	return -1
+}
+
+// encode instruction with its operands
+func (a *assembler) instruction(enc encoding, operands []string) {
+	want := map[operandForm]int{
+		formNone: 0, formDstReg: 1, formSrcReg: 1, formMov: 2, formMvi: 2,
+		formPair: 1, formPairImm: 2, formPairPSW: 1, formPairBD: 1,
+		formImm8: 1, formImm16: 1, formRst: 1,
+	}[enc.form]
+	if len(operands) != want {
+		a.error("expected %d operand(s), got %d", want, len(operands))
+		a.pc += enc.size()
+		return
+	}
+
+	switch enc.form {
+	case formNone:
+		a.emit(enc.opcode)
+	case formDstReg:
+		a.emit(enc.opcode | a.register(operands[0])<<3)
+	case formSrcReg:
+		a.emit(enc.opcode | a.register(operands[0]))
+	case formMov:
+		dst := a.register(operands[0])
+		src := a.register(operands[1])
+		if dst == 6 && src == 6 {
+			a.error("MOV M,M is not valid")
+		}
+		a.emit(enc.opcode | dst<<3 | src)
+	case formMvi:
+		a.emit(enc.opcode|a.register(operands[0])<<3, a.byteValue(operands[1]))
+	case formPair:
+		a.emit(enc.opcode | a.pair(operands[0], "B", "D", "H", "SP")<<4)
+	case formPairImm:
+		value := a.wordValue(operands[1])
+		a.emit(enc.opcode|a.pair(operands[0], "B", "D", "H", "SP")<<4, byte(value), byte(value>>8))
+	case formPairPSW:
+		a.emit(enc.opcode | a.pair(operands[0], "B", "D", "H", "PSW")<<4)
+	case formPairBD:
+		a.emit(enc.opcode | a.pair(operands[0], "B", "D")<<4)
+	case formImm8:
+		a.emit(enc.opcode, a.byteValue(operands[0]))
+	case formImm16:
+		value := a.wordValue(operands[0])
+		a.emit(enc.opcode, byte(value), byte(value>>8))
+	case formRst:
+		n, ok := a.evaluate(operands[0])
+		if ok && (n < 0 || n > 7) {
+			a.error("RST number out of range: %d", n)
+		}
+		a.emit(enc.opcode | byte(n&0x07)<<3)
+	}
+}
+
+// emit bytes at the location counter
+func (a *assembler) emit(data ...byte) {
+	if a.pass == 2 {
+		a.line.Bytes = append(a.line.Bytes, data...)
+		p := a.program
+		for _, b := range data {
+			last := len(p.Segments) - 1
+			if last < 0 || int(p.Segments[last].Address)+len(p.Segments[last].Data) != a.pc {
+				p.Segments = append(p.Segments, Segment{Address: uint16(a.pc)})
+				last++
+			}
+			p.Segments[last].Data = append(p.Segments[last].Data, b)
+			a.pc = (a.pc + 1) & 0xFFFF
+		}
+		return
+	}
+	a.pc = (a.pc + len(data)) & 0xFFFF
+}
+
+func (a *assembler) active() bool {
+	for _, c := range a.conditions {
+		if !c {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *assembler) define(name string, value int, isSet bool) {
+	name = strings.ToUpper(name)
+	_, isRegister := registers[name]
+	_, isPair := pairs[name]
+	if isRegister || isPair {
+		a.error("reserved name %s", name)
+		return
+	}
+	sym, ok := a.symbols[name]
+	if ok && a.pass == 1 && !(sym.isSet && isSet) {
+		a.error("duplicate symbol %s", name)
+		return
+	}
+	a.symbols[name] = &symbol{value: value, isSet: isSet}
+}
+
+func (a *assembler) lookup(name string) (int, error) {
+	if name == "$" {
+		return a.start, nil
+	}
+	sym, ok := a.symbols[name]
+	if !ok {
+		return 0, &undefinedError{name: name}
+	}
+	return sym.value, nil
+}
+
+// evaluate expression, undefined symbols are allowed in pass 1 (forward references)
+func (a *assembler) evaluate(expr string) (int, bool) {
+	value, err := evaluate(expr, a.lookup)
+	if err != nil {
+		var undefined *undefinedError
+		if a.pass == 1 && errors.As(err, &undefined) {
+			return 0, false
+		}
+		a.error("%s", err)
+		return 0, false
+	}
+	return value, true
+}
+
+// evaluate expression which must be known in pass 1 (ORG, DS, IF)
+func (a *assembler) definedValue(expr string) int {
+	value, err := evaluate(expr, a.lookup)
+	if err != nil {
+		a.error("%s", err)
+		return 0
+	}
+	return value
+}
+
+func (a *assembler) byteValue(expr string) byte {
+	value, ok := a.evaluate(expr)
+	if ok && (value < -256 || value > 255) {
+		a.error("byte value out of range: %s", expr)
+	}
+	return byte(value)
+}
+
+func (a *assembler) wordValue(expr string) uint16 {
+	value, ok := a.evaluate(expr)
+	if ok && (value < -65536 || value > 65535) {
+		a.error("word value out of range: %s", expr)
+	}
+	return uint16(value)
+}
+
+// register name (B, C, D, E, H, L, M, A) or expression 0-7
+func (a *assembler) register(operand string) byte {
+	name := strings.ToUpper(strings.TrimSpace(operand))
+	if code, ok := registers[name]; ok {
+		return byte(code)
+	}
+	value, ok := a.evaluate(operand)
+	if ok && (value < 0 || value > 7) {
+		a.error("invalid register %s", operand)
+	}
+	return byte(value & 0x07)
+}
+
+// register pair name limited to the allowed ones
+func (a *assembler) pair(operand string, allowed ...string) byte {
+	name := strings.ToUpper(strings.TrimSpace(operand))
+	for _, p := range allowed {
+		if name == p {
+			return byte(pairs[name] >> 1)
+		}
+	}
+	a.error("invalid register pair %s", operand)
+	return 0
+}
+
+// split source line into label, operation and operands
+func splitLine(text string) (label string, op string, operands string) {
+	code := stripComment(text)
+	if strings.TrimSpace(code) == "" || code[0] == '*' {
+		return "", "", ""
+	}
+	inColumn1 := code[0] != ' ' && code[0] != '\t'
+
+	word, rest := nextWord(code)
+	if strings.HasPrefix(rest, ":") {
+		label = word
+		rest = rest[1:]
+	} else {
+		next, _ := nextWord(rest)
+		switch upper := strings.ToUpper(next); {
+		case upper == "EQU" || upper == "SET" || upper == "DEFL" || upper == "=":
+			label = word
+		case inColumn1 && !isOperation(word):
+			label = word
+		default:
+			return "", word, strings.TrimSpace(rest)
+		}
+	}
+
+	op, rest = nextWord(rest)
+	return label, op, strings.TrimSpace(rest)
+}
+
+// first word (up to space or colon) and the remaining text
+func nextWord(text string) (string, string) {
+	text = strings.TrimLeft(text, " \t")
+	if strings.HasPrefix(text, "=") {
+		return "=", text[1:]
+	}
+	end := strings.IndexAny(text, " \t:")
+	if end < 0 {
+		return text, ""
+	}
+	return text[:end], text[end:]
+}
+
+func isOperation(word string) bool {
+	upper := strings.ToUpper(word)
+	if _, ok := instructions[upper]; ok {
+		return true
+	}
+	switch upper {
+	case "ORG", "DB", "DEFB", "DW", "DEFW", "DS", "DEFS", "END", "IF", "ELSE", "ENDIF":
+		return true
+	}
+	return false
+}
+
+// remove ; comment outside of quotes
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';':
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return strings.TrimRight(text, " \t")
+}
+
+// split operands by commas outside of quotes and parentheses
+func splitOperands(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	var parts []string
+	var quote byte
+	depth := 0
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(text[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(text[start:]))
+}
+
+// content of operand when it is a whole quoted string
+func quotedString(operand string) (string, bool) {
+	if operand == "" || (operand[0] != '\'' && operand[0] != '"') {
+		return "", false
+	}
+	s, n, err := parseQuoted(operand)
+	if err != nil || n != len(operand) {
+		return "", false
+	}
+	return s, true
+}
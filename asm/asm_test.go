@@ -0,0 +1,245 @@
+package asm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/cpudiag.asm assembled by hand from the Intel 8080 opcode table
+const cpudiagBytes = "318501C338010D0A20435055204953204F5045524154494F4E414C240D0A2043" +
+	"505520484153204641494C45442124D5EB0E09CD0500D1C93E01E601C24201CD" +
+	"6601AFDA660106FF0478FE00C2660121730136AA7EFEAAC26601F5F139210601" +
+	"CD2F01C30000211C01E3227301CD2F01C30000"
+
+func assembleFile(t *testing.T, path string) *Program {
+	t.Helper()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Assemble(string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func reference(t *testing.T) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(cpudiagBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBinary(t *testing.T) {
+	p := assembleFile(t, "testdata/cpudiag.asm")
+	if p.Origin != 0x0100 || p.Entry != 0x0100 {
+		t.Errorf("origin %04X entry %04X, want 0100 0100", p.Origin, p.Entry)
+	}
+	if got, want := p.Binary(), reference(t); !bytes.Equal(got, want) {
+		t.Errorf("binary\n got %X\nwant %X", got, want)
+	}
+	symbols := map[string]uint16{"MSG": 0x012F, "CPUER": 0x0166, "TEMP": 0x0173, "STACK": 0x0185}
+	for name, want := range symbols {
+		if got := p.Symbols[name]; got != want {
+			t.Errorf("symbol %s: got %04X, want %04X", name, got, want)
+		}
+	}
+}
+
+func TestWriteHex(t *testing.T) {
+	p := assembleFile(t, "testdata/cpudiag.asm")
+	var buf bytes.Buffer
+	if err := p.WriteHex(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// decode the records back into memory
+	memory := map[int]byte{}
+	ended := false
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if ended {
+			t.Fatalf("record after end of file: %s", line)
+		}
+		if !strings.HasPrefix(line, ":") {
+			t.Fatalf("invalid record %s", line)
+		}
+		record, err := hex.DecodeString(line[1:])
+		if err != nil || len(record) < 5 || len(record) != int(record[0])+5 {
+			t.Fatalf("invalid record %s", line)
+		}
+		sum := byte(0)
+		for _, b := range record {
+			sum += b
+		}
+		if sum != 0 {
+			t.Errorf("checksum of %s", line)
+		}
+		address := int(record[1])<<8 | int(record[2])
+		switch record[3] {
+		case 0x00:
+			for i, b := range record[4 : len(record)-1] {
+				memory[address+i] = b
+			}
+		case 0x01:
+			ended = true
+		default:
+			t.Errorf("record type %02X", record[3])
+		}
+	}
+	if !ended {
+		t.Error("missing end of file record")
+	}
+
+	want := reference(t)
+	if len(memory) != len(want) {
+		t.Errorf("%d bytes, want %d", len(memory), len(want))
+	}
+	for i, b := range want {
+		if memory[0x0100+i] != b {
+			t.Errorf("%04X: got %02X, want %02X", 0x0100+i, memory[0x0100+i], b)
+		}
+	}
+}
+
+func TestWriteListing(t *testing.T) {
+	p := assembleFile(t, "testdata/cpudiag.asm")
+	var buf bytes.Buffer
+	if err := p.WriteListing(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/cpudiag.lst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(buf.String(), "\n")
+	lines := strings.Split(string(want), "\n")
+	for i, line := range lines {
+		if i >= len(got) {
+			t.Fatalf("listing ends before line %d", i+1)
+		}
+		if got[i] != line {
+			t.Errorf("line %d\n got %q\nwant %q", i+1, got[i], line)
+		}
+	}
+	if len(got) > len(lines) {
+		t.Errorf("listing has %d extra lines", len(got)-len(lines))
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		line    int
+		message string
+	}{
+		{"duplicate label", "X:\tNOP\nX:\tNOP\n", 2, "duplicate symbol X"},
+		{"missing operand", "\tMVI\tA\n", 1, "expected 2 operand(s), got 1"},
+		{"extra operand", "\tNOP\t1\n", 1, "expected 0 operand(s), got 1"},
+		{"rst out of range", "\tNOP\n\tRST\t8\n", 2, "RST number out of range: 8"},
+		{"undefined symbol", "\tJMP\tNOWHERE\n", 1, "NOWHERE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Assemble(tt.src)
+			if err == nil {
+				t.Fatalf("assembled %X", p.Binary())
+			}
+			var asmErr *Error
+			if !errors.As(err, &asmErr) {
+				t.Fatalf("error %v is not an asm.Error", err)
+			}
+			if asmErr.Line != tt.line || !strings.Contains(asmErr.Message, tt.message) {
+				t.Errorf("got %v, want line %d: %s", err, tt.line, tt.message)
+			}
+		})
+	}
+}
+
+func TestForwardReferences(t *testing.T) {
+	// instruction sizes do not depend on operands, forward references
+	// resolve in pass 2 without moving later labels
+	src := "\tORG\t100H\n" +
+		"\tJMP\tNEXT\n" +
+		"\tLXI\tH,DATA\n" +
+		"\tMVI\tA,SIZE\n" +
+		"NEXT:\tNOP\n" +
+		"DATA:\tDW\tNEXT,DATA\n" +
+		"SIZE\tEQU\t$-DATA\n"
+	p, err := Assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0xC3, 0x08, 0x01, 0x21, 0x09, 0x01, 0x3E, 0x04, 0x00, 0x08, 0x01, 0x09, 0x01}
+	if !bytes.Equal(p.Binary(), want) {
+		t.Errorf("got %X, want %X", p.Binary(), want)
+	}
+}
+
+// forward references which would change the size or address of
+// later lines between pass 1 and pass 2 are errors
+func TestForwardReferenceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"DS size", "\tDS\tSIZE\nNEXT:\tNOP\nSIZE\tEQU\t4\n"},
+		{"ORG address", "\tORG\tSTART\nSTART\tEQU\t200H\n"},
+		{"IF condition", "\tIF\tFLAG\n\tNOP\n\tENDIF\nNEXT:\tNOP\nFLAG\tEQU\t1\n"},
+		{"EQU chain", "\tMVI\tA,X\nX\tEQU\tY\nY\tEQU\t5\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Assemble(tt.src)
+			if err == nil {
+				t.Fatalf("assembled %X", p.Binary())
+			}
+			var asmErr *Error
+			if !errors.As(err, &asmErr) || asmErr.Line != 1 || !strings.Contains(asmErr.Message, "undefined symbol") {
+				t.Errorf("got %v, want undefined symbol at line 1", err)
+			}
+		})
+	}
+}
+
+// published diagnostic sources (e.g. CPUDIAG.ASM) with the .COM file built
+// from them, as NAME.ASM and NAME.COM in testdata/published
+func TestPublishedDiagnostics(t *testing.T) {
+	sources, _ := filepath.Glob("testdata/published/*.ASM")
+	if len(sources) == 0 {
+		t.Skip("no published diagnostics in testdata/published")
+	}
+	for _, source := range sources {
+		t.Run(filepath.Base(source), func(t *testing.T) {
+			p := assembleFile(t, source)
+			want, err := os.ReadFile(strings.TrimSuffix(source, ".ASM") + ".COM")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := p.Binary()
+			if p.Origin != 0x0100 {
+				t.Errorf("origin %04X, want 0100", p.Origin)
+			}
+			// .COM files are padded to 128 byte records
+			if len(got) > len(want) {
+				t.Fatalf("%d bytes, the .COM file has %d", len(got), len(want))
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("%04X: got %02X, want %02X", 0x0100+i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
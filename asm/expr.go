@@ -0,0 +1,350 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// undefinedError - expression refers to a symbol which is not (yet) defined
+type undefinedError struct {
+	name string
+}
+
+func (e *undefinedError) Error() string {
+	return fmt.Sprintf("undefined symbol %s", e.name)
+}
+
+// expression token
+type token struct {
+	kind  tokenKind
+	text  string // upper case for names and operators
+	value int
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenName
+	tokenOperator
+	tokenEnd
+)
+
+// word operators of Intel assembler
+var wordOperators = map[string]bool{
+	"MOD": true, "SHL": true, "SHR": true,
+	"AND": true, "OR": true, "XOR": true, "NOT": true,
+	"HIGH": true, "LOW": true,
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_' || c == '?' || c == '@' || c == '.'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9' || c == '$'
+}
+
+// split expression text into tokens
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(text) && isNameChar(text[i]) {
+				i++
+			}
+			value, err := parseNumber(text[start:i])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: value})
+
+		case c == '\'' || c == '"':
+			s, n, err := parseQuoted(text[i:])
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			if len(s) == 0 || len(s) > 2 {
+				return nil, fmt.Errorf("invalid character constant %s", text[i-n:i])
+			}
+			value := 0
+			for j := 0; j < len(s); j++ {
+				value = value<<8 | int(s[j])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: value})
+
+		case c == '$' && (i+1 >= len(text) || !isNameChar(text[i+1])):
+			// current location counter
+			tokens = append(tokens, token{kind: tokenName, text: "$"})
+			i++
+
+		case c == '$':
+			// $1F hex notation
+			start := i + 1
+			i++
+			for i < len(text) && isNameChar(text[i]) {
+				i++
+			}
+			value, err := strconv.ParseUint(text[start:i], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s", text[start-1:i])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: int(value)})
+
+		case isNameStart(c):
+			start := i
+			for i < len(text) && isNameChar(text[i]) {
+				i++
+			}
+			name := strings.ToUpper(text[start:i])
+			if wordOperators[name] {
+				tokens = append(tokens, token{kind: tokenOperator, text: name})
+			} else {
+				tokens = append(tokens, token{kind: tokenName, text: name})
+			}
+
+		default:
+			if i+1 < len(text) {
+				two := text[i : i+2]
+				if two == "<<" || two == ">>" {
+					tokens = append(tokens, token{kind: tokenOperator, text: two})
+					i += 2
+					continue
+				}
+			}
+			if strings.IndexByte("+-*/%&|^~()", c) < 0 {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: string(c)})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokenEnd}), nil
+}
+
+// parse number with Intel suffix (H, B, O, Q, D) or 0x prefix
+func parseNumber(text string) (int, error) {
+	s := strings.ToUpper(text)
+	base := 10
+	switch {
+	case strings.HasPrefix(s, "0X"):
+		s = s[2:]
+		base = 16
+	case strings.HasSuffix(s, "H"):
+		s = s[:len(s)-1]
+		base = 16
+	case strings.HasSuffix(s, "B"):
+		s = s[:len(s)-1]
+		base = 2
+	case strings.HasSuffix(s, "O") || strings.HasSuffix(s, "Q"):
+		s = s[:len(s)-1]
+		base = 8
+	case strings.HasSuffix(s, "D"):
+		s = s[:len(s)-1]
+	}
+	value, err := strconv.ParseUint(s, base, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s", text)
+	}
+	return int(value), nil
+}
+
+// parse quoted string at the start of text, a doubled quote is an escaped quote
+// returns the content and the consumed length
+func parseQuoted(text string) (string, int, error) {
+	quote := text[0]
+	var sb strings.Builder
+	for i := 1; i < len(text); i++ {
+		if text[i] != quote {
+			sb.WriteByte(text[i])
+			continue
+		}
+		if i+1 < len(text) && text[i+1] == quote {
+			sb.WriteByte(quote)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("unterminated string %s", text)
+}
+
+// expression parser (recursive descent)
+//
+//	or     = and { (OR | XOR | '|' | '^') and }
+//	and    = sum { (AND | '&') sum }
+//	sum    = term { ('+' | '-') term }
+//	term   = unary { ('*' | '/' | MOD | '%' | SHL | SHR | '<<' | '>>') unary }
+//	unary  = ('-' | '+' | NOT | '~' | HIGH | LOW) unary | primary
+//	primary = number | name | '$' | '(' or ')'
+type parser struct {
+	tokens []token
+	pos    int
+	lookup func(name string) (int, error)
+}
+
+// evaluate expression text, names are resolved by lookup
+func evaluate(text string, lookup func(name string) (int, error)) (int, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return 0, err
+	}
+	if len(tokens) == 1 {
+		return 0, fmt.Errorf("missing expression")
+	}
+	p := &parser{tokens: tokens, lookup: lookup}
+	value, err := p.or()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek().kind != tokenEnd {
+		return 0, fmt.Errorf("unexpected %s in expression %s", p.peek().describe(), text)
+	}
+	return value, nil
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenNumber:
+		return strconv.Itoa(t.value)
+	case tokenEnd:
+		return "end"
+	}
+	return t.text
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+// accept operator if it is one of ops
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// binary operator level, the first error (e.g. undefined symbol) is kept
+// while parsing continues so syntax errors are still found
+func (p *parser) binary(operand func() (int, error), apply func(op string, a, b int) (int, error), ops ...string) (int, error) {
+	value, err := operand()
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return value, err
+		}
+		right, rerr := operand()
+		if err == nil {
+			err = rerr
+		}
+		if err == nil {
+			value, err = apply(op, value, right)
+		}
+	}
+}
+
+func (p *parser) or() (int, error) {
+	return p.binary(p.and, func(op string, a, b int) (int, error) {
+		if op == "XOR" || op == "^" {
+			return a ^ b, nil
+		}
+		return a | b, nil
+	}, "OR", "XOR", "|", "^")
+}
+
+func (p *parser) and() (int, error) {
+	return p.binary(p.sum, func(op string, a, b int) (int, error) {
+		return a & b, nil
+	}, "AND", "&")
+}
+
+func (p *parser) sum() (int, error) {
+	return p.binary(p.term, func(op string, a, b int) (int, error) {
+		if op == "-" {
+			return a - b, nil
+		}
+		return a + b, nil
+	}, "+", "-")
+}
+
+func (p *parser) term() (int, error) {
+	return p.binary(p.unary, func(op string, a, b int) (int, error) {
+		switch op {
+		case "*":
+			return a * b, nil
+		case "/", "MOD", "%":
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			if op == "/" {
+				return a / b, nil
+			}
+			return a % b, nil
+		case "SHL", "<<":
+			return a << uint(b&0x1F), nil
+		}
+		return int(uint16(a) >> uint(b&0x1F)), nil
+	}, "*", "/", "MOD", "%", "SHL", "SHR", "<<", ">>")
+}
+
+func (p *parser) unary() (int, error) {
+	if op, ok := p.accept("-", "+", "NOT", "~", "HIGH", "LOW"); ok {
+		value, err := p.unary()
+		switch op {
+		case "-":
+			value = -value
+		case "NOT", "~":
+			value = ^value & 0xFFFF
+		case "HIGH":
+			value = (value >> 8) & 0xFF
+		case "LOW":
+			value = value & 0xFF
+		}
+		return value, err
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (int, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return t.value, nil
+	case tokenName:
+		return p.lookup(t.text)
+	case tokenOperator:
+		if t.text == "(" {
+			value, err := p.or()
+			if _, ok := p.accept(")"); !ok {
+				return 0, fmt.Errorf("missing )")
+			}
+			return value, err
+		}
+	}
+	return 0, fmt.Errorf("unexpected %s in expression", t.describe())
+}
@@ -0,0 +1,136 @@
+package asm
+
+// operand forms of 8080 instructions
+type operandForm int
+
+const (
+	formNone    operandForm = iota // NOP
+	formDstReg                     // INR r - register in bits 3-5
+	formSrcReg                     // ADD r - register in bits 0-2
+	formMov                        // MOV d,s
+	formMvi                        // MVI r,d8
+	formPair                       // INX rp - B, D, H or SP in bits 4-5
+	formPairImm                    // LXI rp,d16
+	formPairPSW                    // PUSH rp - B, D, H or PSW in bits 4-5
+	formPairBD                     // STAX rp - B or D only
+	formImm8                       // ADI d8
+	formImm16                      // JMP a16
+	formRst                        // RST n
+)
+
+type encoding struct {
+	opcode byte
+	form   operandForm
+}
+
+// instruction size in bytes of each form
+func (e encoding) size() int {
+	switch e.form {
+	case formMvi, formImm8:
+		return 2
+	case formPairImm, formImm16:
+		return 3
+	}
+	return 1
+}
+
+var instructions = map[string]encoding{
+	// no operand
+	"NOP":  {0x00, formNone},
+	"RLC":  {0x07, formNone},
+	"RRC":  {0x0F, formNone},
+	"RAL":  {0x17, formNone},
+	"RAR":  {0x1F, formNone},
+	"DAA":  {0x27, formNone},
+	"CMA":  {0x2F, formNone},
+	"STC":  {0x37, formNone},
+	"CMC":  {0x3F, formNone},
+	"HLT":  {0x76, formNone},
+	"RET":  {0xC9, formNone},
+	"RNZ":  {0xC0, formNone},
+	"RZ":   {0xC8, formNone},
+	"RNC":  {0xD0, formNone},
+	"RC":   {0xD8, formNone},
+	"RPO":  {0xE0, formNone},
+	"RPE":  {0xE8, formNone},
+	"RP":   {0xF0, formNone},
+	"RM":   {0xF8, formNone},
+	"XTHL": {0xE3, formNone},
+	"PCHL": {0xE9, formNone},
+	"XCHG": {0xEB, formNone},
+	"SPHL": {0xF9, formNone},
+	"DI":   {0xF3, formNone},
+	"EI":   {0xFB, formNone},
+
+	// 8-bit register or memory
+	"INR": {0x04, formDstReg},
+	"DCR": {0x05, formDstReg},
+	"ADD": {0x80, formSrcReg},
+	"ADC": {0x88, formSrcReg},
+	"SUB": {0x90, formSrcReg},
+	"SBB": {0x98, formSrcReg},
+	"ANA": {0xA0, formSrcReg},
+	"XRA": {0xA8, formSrcReg},
+	"ORA": {0xB0, formSrcReg},
+	"CMP": {0xB8, formSrcReg},
+	"MOV": {0x40, formMov},
+	"MVI": {0x06, formMvi},
+
+	// register pair
+	"LXI":  {0x01, formPairImm},
+	"DAD":  {0x09, formPair},
+	"INX":  {0x03, formPair},
+	"DCX":  {0x0B, formPair},
+	"PUSH": {0xC5, formPairPSW},
+	"POP":  {0xC1, formPairPSW},
+	"STAX": {0x02, formPairBD},
+	"LDAX": {0x0A, formPairBD},
+
+	// immediate
+	"ADI": {0xC6, formImm8},
+	"ACI": {0xCE, formImm8},
+	"SUI": {0xD6, formImm8},
+	"SBI": {0xDE, formImm8},
+	"ANI": {0xE6, formImm8},
+	"XRI": {0xEE, formImm8},
+	"ORI": {0xF6, formImm8},
+	"CPI": {0xFE, formImm8},
+	"IN":  {0xDB, formImm8},
+	"OUT": {0xD3, formImm8},
+
+	// address
+	"JMP":  {0xC3, formImm16},
+	"JNZ":  {0xC2, formImm16},
+	"JZ":   {0xCA, formImm16},
+	"JNC":  {0xD2, formImm16},
+	"JC":   {0xDA, formImm16},
+	"JPO":  {0xE2, formImm16},
+	"JPE":  {0xEA, formImm16},
+	"JP":   {0xF2, formImm16},
+	"JM":   {0xFA, formImm16},
+	"CALL": {0xCD, formImm16},
+	"CNZ":  {0xC4, formImm16},
+	"CZ":   {0xCC, formImm16},
+	"CNC":  {0xD4, formImm16},
+	"CC":   {0xDC, formImm16},
+	"CPO":  {0xE4, formImm16},
+	"CPE":  {0xEC, formImm16},
+	"CP":   {0xF4, formImm16},
+	"CM":   {0xFC, formImm16},
+	"LDA":  {0x3A, formImm16},
+	"STA":  {0x32, formImm16},
+	"LHLD": {0x2A, formImm16},
+	"SHLD": {0x22, formImm16},
+
+	"RST": {0xC7, formRst},
+}
+
+// register codes
+var registers = map[string]int{
+	"B": 0, "C": 1, "D": 2, "E": 3, "H": 4, "L": 5, "M": 6, "A": 7,
+}
+
+// register pair codes (Intel convention, B=0 D=2 H=4 SP/PSW=6)
+var pairs = map[string]int{
+	"B": 0, "D": 2, "H": 4, "SP": 6, "PSW": 6,
+}
@@ -0,0 +1,103 @@
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Binary - flat image from Origin to the last assembled byte, gaps are filled with 0
+func (p *Program) Binary() []byte {
+	end := 0
+	for _, seg := range p.Segments {
+		if e := int(seg.Address) + len(seg.Data); e > end {
+			end = e
+		}
+	}
+	if end == 0 {
+		return nil
+	}
+	image := make([]byte, end-int(p.Origin))
+	for _, seg := range p.Segments {
+		copy(image[int(seg.Address)-int(p.Origin):], seg.Data)
+	}
+	return image
+}
+
+// WriteHex - write Intel HEX records (16 bytes per record)
+func (p *Program) WriteHex(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, seg := range p.Segments {
+		for offset := 0; offset < len(seg.Data); offset += 16 {
+			end := offset + 16
+			if end > len(seg.Data) {
+				end = len(seg.Data)
+			}
+			writeHexRecord(bw, seg.Address+uint16(offset), 0x00, seg.Data[offset:end])
+		}
+	}
+	writeHexRecord(bw, 0, 0x01, nil)
+	return bw.Flush()
+}
+
+// :LLAAAATT[DD...]CC
+func writeHexRecord(w io.Writer, address uint16, recordType byte, data []byte) {
+	sum := byte(len(data)) + byte(address>>8) + byte(address) + recordType
+	fmt.Fprintf(w, ":%02X%04X%02X", len(data), address, recordType)
+	for _, b := range data {
+		fmt.Fprintf(w, "%02X", b)
+		sum += b
+	}
+	fmt.Fprintf(w, "%02X\n", -sum)
+}
+
+// WriteListing - write listing with address, bytes, line number and source,
+// followed by the symbol table
+func (p *Program) WriteListing(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range p.Lines {
+		address := "    "
+		if line.Address >= 0 {
+			address = fmt.Sprintf("%04X", line.Address)
+		}
+		code := ""
+		switch {
+		case line.Value >= 0:
+			address = fmt.Sprintf("%04X", line.Value)
+			code = "="
+		case len(line.Bytes) > 0:
+			code = hexBytes(line.Bytes, 4)
+		}
+		fmt.Fprintf(bw, "%s %-8s %5d  %s\n", address, code, line.Number, line.Source)
+
+		// bytes which do not fit the first line (e.g. long DB)
+		for i := 4; i < len(line.Bytes); i += 4 {
+			fmt.Fprintf(bw, "%04X %s\n", line.Address+i, hexBytes(line.Bytes[i:], 4))
+		}
+	}
+
+	names := make([]string, 0, len(p.Symbols))
+	for name := range p.Symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(bw, "\nSYMBOLS\n")
+	for _, name := range names {
+		fmt.Fprintf(bw, "%04X %s\n", p.Symbols[name], name)
+	}
+	return bw.Flush()
+}
+
+// first n bytes in hex without separator
+func hexBytes(data []byte, n int) string {
+	if len(data) > n {
+		data = data[:n]
+	}
+	var sb strings.Builder
+	for _, b := range data {
+		fmt.Fprintf(&sb, "%02X", b)
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+; opening of a CPUDIAG style 8080 CPU diagnostic (CP/M .COM at 0100H),
+; prints a message through BDOS and returns to CP/M
+BDOS	EQU	5
+WBOOT	EQU	0
+PRINT	EQU	9
+	ORG	00100H
+CPU:	LXI	SP,STACK	;SET THE STACK POINTER
+	JMP	CPUTST
+OKCPU:	DB	0DH,0AH,' CPU IS OPERATIONAL$'
+NGCPU:	DB	0DH,0AH,' CPU HAS FAILED!$'
+;
+;MESSAGE OUTPUT ROUTINE
+MSG:	PUSH	D	;EXILE D REG.
+	XCHG		;SWAP H&L REGS. TO D&E REGS.
+	MVI	C,PRINT	;LET BDOS KNOW WE WANT TO PRINT LINE
+	CALL	BDOS
+	POP	D	;BACK FROM EXILE
+	RET
+;
+CPUTST:	MVI	A,001H	;TEST "JZ"
+	ANI	001H
+	JNZ	J010
+	CALL	CPUER
+J010:	XRA	A	;TEST "JC"
+	JC	CPUER
+	MVI	B,0FFH	;TEST "INR" AND "MOV"
+	INR	B
+	MOV	A,B
+	CPI	0
+	JNZ	CPUER
+	LXI	H,TEMP	;TEST "MVI M" AND "MOV A,M"
+	MVI	M,0AAH
+	MOV	A,M
+	CPI	0AAH
+	JNZ	CPUER
+	PUSH	PSW
+	POP	PSW
+	DAD	SP
+	LXI	H,OKCPU
+	CALL	MSG
+	JMP	WBOOT
+CPUER:	LXI	H,NGCPU
+	XTHL
+	SHLD	TEMP
+	CALL	MSG
+	JMP	WBOOT
+TEMP:	DS	2
+	DS	16
+STACK	EQU	$
+	END	CPU
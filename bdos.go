@@ -0,0 +1,824 @@
+package gomu8080
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// memory layout of the emulated CP/M system
+const (
+	BDOSBase  = 0xFE00
+	BDOSEntry = BDOSBase + 0x06
+	BIOSBase  = 0xFF00
+
+	// disk parameter block and allocation vector of the host drives
+	bdosDPB = BDOSBase + 0x10
+	bdosALV = BDOSBase + 0x20
+
+	// page zero
+	cpmIOByte     = 0x0003
+	cpmDriveUser  = 0x0004
+	cpmFCB1       = 0x005C
+	cpmFCB2       = 0x006C
+	cpmDefaultDMA = 0x0080
+
+	cpmRecordSize = 128
+)
+
+// BIOS jump vector, console entries are served by the BDOS,
+// warm boot halts the processor and disk entries are not supported
+const biosSource = `
+BDOS    EQU     0005H
+        ORG     BIOSBASE
+        JMP     WBOOT   ; BOOT
+        JMP     WBOOT   ; WBOOT
+        JMP     CONST
+        JMP     CONIN
+        JMP     CONOUT
+        JMP     LIST
+        JMP     PUNCH
+        JMP     READER
+        JMP     NODISK  ; HOME
+        JMP     NODISK  ; SELDSK
+        JMP     NODISK  ; SETTRK
+        JMP     NODISK  ; SETSEC
+        JMP     NODISK  ; SETDMA
+        JMP     DSKERR  ; READ
+        JMP     DSKERR  ; WRITE
+        JMP     LISTST
+        JMP     SECTRAN
+WBOOT:  DI
+        HLT
+CONST:  MVI     C,11
+        JMP     BDOS
+CONIN:  MVI     C,1
+        JMP     BDOS
+CONOUT: MOV     E,C
+        MVI     C,2
+        JMP     BDOS
+LIST:   MOV     E,C
+        MVI     C,5
+        JMP     BDOS
+PUNCH:  MOV     E,C
+        MVI     C,4
+        JMP     BDOS
+READER: MVI     C,3
+        JMP     BDOS
+NODISK: LXI     H,0
+        XRA     A
+        RET
+DSKERR: MVI     A,1
+        RET
+LISTST: XRA     A
+        RET
+SECTRAN: MOV    H,B
+        MOV     L,C
+        RET
+`
+
+// disk parameter block - 2K blocks, 256 blocks, 128 directory entries
+var bdosDiskParameters = []byte{
+	64, 0, // SPT
+	4,      // BSH
+	15,     // BLM
+	1,      // EXM
+	255, 0, // DSM
+	127, 0, // DRM
+	0xC0, 0, // AL0 AL1
+	0, 0, // CKS
+	0, 0, // OFF
+}
+
+// BDOS - CP/M 2.2 BDOS emulation
+// CALL 0005 is hooked and served by Call (see Attach), files are kept in
+// the host directory Dir (drive A), drives B to P are its subdirectories B to P.
+type BDOS struct {
+	Dir     string
+	Console *Console
+
+	dma   uint16
+	drive byte
+	user  byte
+
+	// pending directory entries of search first/next
+	search []string
+
+	// host files kept open between calls, by drive and CP/M name
+	files map[string]*os.File
+}
+
+func NewBDOS(dir string, in io.Reader, out io.Writer) *BDOS {
+	b := &BDOS{}
+	b.Dir = dir
+	b.Console = NewConsole(in, out)
+	b.dma = cpmDefaultDMA
+	b.files = map[string]*os.File{}
+	return b
+}
+
+// Boot - set up page zero, BDOS entry and BIOS vector, the command tail and
+// default FCBs from args, and a stack returning to warm boot
+func (b *BDOS) Boot(p *Processor, args []string) error {
+	m := p.memory
+
+	bios, err := asm.Assemble(fmt.Sprintf("BIOSBASE EQU 0%04XH\n%s", BIOSBase, biosSource))
+	if err != nil {
+		return err
+	}
+	for i, data := range bios.Binary() {
+		m.Write(bios.Origin+uint16(i), data)
+	}
+
+	// JMP WBOOT, IOBYTE, drive and JMP BDOS
+	wboot, entry := uint16(BIOSBase+3), uint16(BDOSEntry)
+	writeBytes(m, 0x0000, []byte{0xC3, byte(wboot), byte(wboot >> 8), 0, 0})
+	writeBytes(m, 0x0005, []byte{0xC3, byte(entry), byte(entry >> 8)})
+
+	// programs calling the BDOS entry directly end up in the hook
+	writeBytes(m, BDOSEntry, []byte{0xCD, 0x05, 0x00, 0xC9}) // CALL 0005, RET
+	writeBytes(m, bdosDPB, bdosDiskParameters)
+	writeBytes(m, bdosALV, make([]byte, 32))
+
+	// command tail and default FCBs
+	tail := strings.ToUpper(strings.Join(args, " "))
+	if tail != "" {
+		tail = " " + tail
+	}
+	if len(tail) > 127 {
+		tail = tail[:127]
+	}
+	m.Write(cpmDefaultDMA, byte(len(tail)))
+	writeBytes(m, cpmDefaultDMA+1, []byte(tail))
+	writeBytes(m, cpmFCB1, make([]byte, 36))
+	fcbs := []uint16{cpmFCB1, cpmFCB2}
+	for i, fcb := range fcbs {
+		arg := ""
+		if i < len(args) {
+			arg = args[i]
+		}
+		writeBytes(m, fcb, parseFileName(arg))
+	}
+
+	b.dma = cpmDefaultDMA
+	b.drive = 0
+	b.user = 0
+
+	// return address of the program
+	p.SP = BDOSBase - 2
+	writeBytes(m, p.SP, []byte{0x00, 0x00})
+	return nil
+}
+
+// Attach - hook CALL 0005 to the BDOS and stop the processor on warm boot (0000)
+func (b *BDOS) Attach(p *Processor) {
+	p.OnCall(0x0005, func(p *Processor) bool {
+		b.Call(p)
+		return true
+	})
+	p.OnExecute(0x0000, func(p *Processor) bool {
+		p.IsHalt = true
+		return true
+	})
+}
+
+// Call - serve BDOS function C with parameter DE,
+// results are returned in A and HL (and B = H, L = A)
+func (b *BDOS) Call(p *Processor) {
+	m := p.memory
+	de := uint16(p.D)<<8 | uint16(p.E)
+	result := uint16(0)
+
+	switch p.C {
+	case 0: // system reset
+		p.IsHalt = true
+
+	case 1: // console input
+		result = uint16(b.Console.ReadChar())
+
+	case 2: // console output
+		b.Console.Write(p.E)
+
+	case 3: // reader input
+		result = 0x1A
+
+	case 4, 5: // punch and list output
+
+	case 6: // direct console I/O
+		switch p.E {
+		case 0xFF:
+			if b.Console.Ready() {
+				result = uint16(b.Console.ReadChar())
+			}
+		case 0xFE:
+			if b.Console.Ready() {
+				result = 0xFF
+			}
+		default:
+			b.Console.Write(p.E)
+		}
+
+	case 7: // get IOBYTE
+		result = uint16(m.Read(cpmIOByte))
+
+	case 8: // set IOBYTE
+		m.Write(cpmIOByte, p.E)
+
+	case 9: // print string
+		for address := de; m.Read(address) != '$'; address++ {
+			b.Console.Write(m.Read(address))
+		}
+
+	case 10: // read console buffer
+		b.readLine(m, de)
+
+	case 11: // console status
+		if b.Console.Ready() {
+			result = 0xFF
+		}
+
+	case 12: // version number
+		result = 0x0022
+
+	case 13: // reset disk system
+		b.closeFiles()
+		b.drive = 0
+		b.dma = cpmDefaultDMA
+
+	case 14: // select disk
+		if _, ok := b.driveDir(p.E + 1); !ok {
+			result = 0xFF
+			break
+		}
+		b.drive = p.E
+		m.Write(cpmDriveUser, b.user<<4|b.drive)
+
+	case 15: // open file
+		result = b.open(m, de)
+
+	case 16: // close file
+		result = b.close(m, de)
+
+	case 17: // search for first
+		result = b.searchFirst(m, de)
+
+	case 18: // search for next
+		result = b.searchNext(m)
+
+	case 19: // delete file
+		result = b.delete(m, de)
+
+	case 20: // read sequential
+		result = b.readSequential(m, de)
+
+	case 21: // write sequential
+		result = b.writeSequential(m, de)
+
+	case 22: // make file
+		result = b.make(m, de)
+
+	case 23: // rename file
+		result = b.rename(m, de)
+
+	case 24: // login vector
+		for drive := byte(1); drive <= 16; drive++ {
+			if _, ok := b.driveDir(drive); ok {
+				result |= 1 << (drive - 1)
+			}
+		}
+
+	case 25: // current disk
+		result = uint16(b.drive)
+
+	case 26: // set DMA address
+		b.dma = de
+
+	case 27: // get allocation vector address
+		result = bdosALV
+
+	case 28, 37: // write protect disk, reset drive
+
+	case 29: // get read-only vector
+
+	case 30: // set file attributes
+		if _, ok := b.lookup(m, de); !ok {
+			result = 0xFF
+		}
+
+	case 31: // get disk parameter block address
+		result = bdosDPB
+
+	case 32: // get or set user code
+		if p.E == 0xFF {
+			result = uint16(b.user)
+		} else {
+			b.user = p.E & 0x0F
+			m.Write(cpmDriveUser, b.user<<4|b.drive)
+		}
+
+	case 33: // read random
+		result = b.readRandom(m, de)
+
+	case 34, 40: // write random, write random with zero fill
+		result = b.writeRandom(m, de)
+
+	case 35: // compute file size
+		result = b.fileSize(m, de)
+
+	case 36: // set random record
+		setRandomRecord(m, de, fcbRecord(m, de))
+
+	default:
+		if p.DebugMode {
+			fmt.Printf("BDOS: unsupported function %d\n", p.C)
+		}
+	}
+
+	p.L = byte(result)
+	p.A = p.L
+	p.H = byte(result >> 8)
+	p.B = p.H
+}
+
+// read line into console buffer (max length, count, characters)
+func (b *BDOS) readLine(m Memory, buffer uint16) {
+	max := int(m.Read(buffer))
+	count := 0
+	for {
+		c := b.Console.Read()
+		if c == '\n' || c == 0x1A {
+			break
+		}
+		if c == '\r' || count >= max {
+			continue
+		}
+		m.Write(buffer+2+uint16(count), c)
+		count++
+	}
+	m.Write(buffer+1, byte(count))
+}
+
+/* files */
+
+// host directory of drive (1 = A, 0 = current drive)
+func (b *BDOS) driveDir(drive byte) (string, bool) {
+	if drive == 0 {
+		drive = b.drive + 1
+	}
+	if drive == 1 {
+		return b.Dir, true
+	}
+	if drive > 16 {
+		return "", false
+	}
+	dir := filepath.Join(b.Dir, string(rune('A'+drive-1)))
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+// CP/M name (8 + 3 characters padded with spaces) of host file name
+func cpmName(name string) (string, bool) {
+	base, ext := name, ""
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		base, ext = name[:i], name[i+1:]
+	}
+	if base == "" || len(base) > 8 || len(ext) > 3 {
+		return "", false
+	}
+	upper := strings.ToUpper(base + ext)
+	for i := 0; i < len(upper); i++ {
+		c := upper[i]
+		if c <= ' ' || c >= 0x7F || strings.IndexByte(`<>.,;:=?*[]|/\`, c) >= 0 {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%-8s%-3s", strings.ToUpper(base), strings.ToUpper(ext)), true
+}
+
+// CP/M name in FCB without attribute bits
+func fcbName(m Memory, fcb uint16) string {
+	name := make([]byte, 11)
+	for i := range name {
+		name[i] = m.Read(fcb+1+uint16(i)) & 0x7F
+	}
+	return string(name)
+}
+
+// wildcard '?' match of CP/M names
+func matchName(pattern string, name string) bool {
+	for i := 0; i < 11; i++ {
+		if pattern[i] != '?' && pattern[i] != name[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FCB fields (drive, name and type) of a command line argument like B:NAME.TYP
+func parseFileName(arg string) []byte {
+	fcb := []byte{0, ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}
+	arg = strings.ToUpper(arg)
+	if len(arg) >= 2 && arg[1] == ':' && arg[0] >= 'A' && arg[0] <= 'P' {
+		fcb[0] = arg[0] - 'A' + 1
+		arg = arg[2:]
+	}
+	base, ext := arg, ""
+	if i := strings.Index(arg, "."); i >= 0 {
+		base, ext = arg[:i], arg[i+1:]
+	}
+	fill := func(field []byte, text string) {
+		for i := 0; i < len(field) && i < len(text); i++ {
+			if text[i] == '*' {
+				for ; i < len(field); i++ {
+					field[i] = '?'
+				}
+				return
+			}
+			field[i] = text[i]
+		}
+	}
+	fill(fcb[1:9], base)
+	fill(fcb[9:12], ext)
+	return fcb
+}
+
+// host files of drive matching CP/M name pattern, sorted by CP/M name
+func (b *BDOS) match(drive byte, pattern string) []string {
+	dir, ok := b.driveDir(drive)
+	if !ok {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	names := map[string]string{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name, ok := cpmName(entry.Name())
+		if !ok || !matchName(pattern, name) {
+			continue
+		}
+		if _, dup := names[name]; dup {
+			continue
+		}
+		names[name] = filepath.Join(dir, entry.Name())
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	for i, name := range paths {
+		paths[i] = names[name]
+	}
+	return paths
+}
+
+// host path of the file named in FCB
+func (b *BDOS) lookup(m Memory, fcb uint16) (string, bool) {
+	paths := b.match(m.Read(fcb), fcbName(m, fcb))
+	if len(paths) == 0 {
+		return "", false
+	}
+	return paths[0], true
+}
+
+// key of open file, drive and CP/M name
+func (b *BDOS) fileKey(m Memory, fcb uint16) string {
+	return b.key(m.Read(fcb), fcbName(m, fcb))
+}
+
+func (b *BDOS) key(drive byte, name string) string {
+	if drive == 0 {
+		drive = b.drive + 1
+	}
+	return string(rune('@'+drive)) + ":" + name
+}
+
+// open host file of FCB, kept open until the file is closed
+func (b *BDOS) file(m Memory, fcb uint16) (*os.File, bool) {
+	key := b.fileKey(m, fcb)
+	if f, ok := b.files[key]; ok {
+		return f, true
+	}
+	path, ok := b.lookup(m, fcb)
+	if !ok {
+		return nil, false
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if f, err = os.Open(path); err != nil {
+			return nil, false
+		}
+	}
+	b.files[key] = f
+	return f, true
+}
+
+func (b *BDOS) closeFile(key string) {
+	if f, ok := b.files[key]; ok {
+		f.Close()
+		delete(b.files, key)
+	}
+}
+
+func (b *BDOS) closeFiles() {
+	for key := range b.files {
+		b.closeFile(key)
+	}
+}
+
+// number of 128-byte records of file
+func fileRecords(f *os.File) int {
+	info, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	return int((info.Size() + cpmRecordSize - 1) / cpmRecordSize)
+}
+
+// current record of FCB from s2, extent and current record fields
+func fcbRecord(m Memory, fcb uint16) int {
+	s2 := int(m.Read(fcb+14)) & 0x3F
+	ex := int(m.Read(fcb+12)) & 0x1F
+	cr := int(m.Read(fcb + 32))
+	return s2*4096 + ex*128 + cr
+}
+
+// set current record of FCB and record count of its extent
+func setFcbRecord(m Memory, fcb uint16, record int, records int) {
+	m.Write(fcb+14, byte(record/4096))
+	m.Write(fcb+12, byte(record/128%32))
+	m.Write(fcb+32, byte(record%128))
+	setRecordCount(m, fcb, records)
+}
+
+// record count (rc) of the current extent
+func setRecordCount(m Memory, fcb uint16, records int) {
+	start := (fcbRecord(m, fcb) / 128) * 128
+	count := records - start
+	if count < 0 {
+		count = 0
+	}
+	if count > 128 {
+		count = 128
+	}
+	m.Write(fcb+15, byte(count))
+}
+
+func randomRecord(m Memory, fcb uint16) (int, bool) {
+	if m.Read(fcb+35) != 0 {
+		return 0, false
+	}
+	return int(m.Read(fcb+33)) | int(m.Read(fcb+34))<<8, true
+}
+
+func setRandomRecord(m Memory, fcb uint16, record int) {
+	m.Write(fcb+33, byte(record))
+	m.Write(fcb+34, byte(record>>8))
+	m.Write(fcb+35, byte(record>>16))
+}
+
+// directory entry of host file at DMA
+func (b *BDOS) writeDirEntry(m Memory, path string) {
+	name, _ := cpmName(filepath.Base(path))
+	entry := make([]byte, 32)
+	entry[0] = b.user
+	copy(entry[1:12], name)
+	if info, err := os.Stat(path); err == nil {
+		records := int((info.Size() + cpmRecordSize - 1) / cpmRecordSize)
+		if records > 0 {
+			last := (records - 1) / 128
+			entry[12] = byte(last % 32)
+			entry[14] = byte(last / 32)
+			entry[15] = byte(records - last*128)
+		}
+		// one allocation block per 2K
+		for i := 0; i < 16 && i*2048 < int(info.Size()); i++ {
+			entry[16+i] = byte(i + 2)
+		}
+	}
+	writeBytes(m, b.dma, entry)
+}
+
+func (b *BDOS) open(m Memory, fcb uint16) uint16 {
+	path, ok := b.lookup(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	// the real name replaces wildcards
+	name, _ := cpmName(filepath.Base(path))
+	writeBytes(m, fcb+1, []byte(name))
+	f, ok := b.file(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	m.Write(fcb+13, 0)
+	setRecordCount(m, fcb, fileRecords(f))
+	return 0
+}
+
+func (b *BDOS) close(m Memory, fcb uint16) uint16 {
+	if _, ok := b.lookup(m, fcb); !ok {
+		return 0xFF
+	}
+	b.closeFile(b.fileKey(m, fcb))
+	return 0
+}
+
+func (b *BDOS) searchFirst(m Memory, fcb uint16) uint16 {
+	pattern := fcbName(m, fcb)
+	drive := m.Read(fcb)
+	if drive == '?' {
+		drive = 0
+		pattern = "???????????"
+	}
+	b.search = b.match(drive, pattern)
+	return b.searchNext(m)
+}
+
+func (b *BDOS) searchNext(m Memory) uint16 {
+	if len(b.search) == 0 {
+		return 0xFF
+	}
+	b.writeDirEntry(m, b.search[0])
+	b.search = b.search[1:]
+	return 0
+}
+
+func (b *BDOS) delete(m Memory, fcb uint16) uint16 {
+	paths := b.match(m.Read(fcb), fcbName(m, fcb))
+	if len(paths) == 0 {
+		return 0xFF
+	}
+	for _, path := range paths {
+		name, _ := cpmName(filepath.Base(path))
+		b.closeFile(b.key(m.Read(fcb), name))
+		os.Remove(path)
+	}
+	return 0
+}
+
+func (b *BDOS) make(m Memory, fcb uint16) uint16 {
+	name := fcbName(m, fcb)
+	if strings.ContainsRune(name, '?') {
+		return 0xFF
+	}
+	dir, ok := b.driveDir(m.Read(fcb))
+	if !ok {
+		return 0xFF
+	}
+	path, exists := b.lookup(m, fcb)
+	if !exists {
+		path = filepath.Join(dir, hostName(name))
+	}
+	flags := os.O_RDWR | os.O_CREATE
+	if m.Read(fcb+12) == 0 && m.Read(fcb+14) == 0 {
+		flags |= os.O_TRUNC
+	}
+	key := b.fileKey(m, fcb)
+	b.closeFile(key)
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return 0xFF
+	}
+	b.files[key] = f
+	m.Write(fcb+13, 0)
+	setRecordCount(m, fcb, fileRecords(f))
+	return 0
+}
+
+// host file name of CP/M name, NAME.TYP
+func hostName(name string) string {
+	base := strings.TrimRight(name[:8], " ")
+	ext := strings.TrimRight(name[8:], " ")
+	if ext == "" {
+		return base
+	}
+	return base + "." + ext
+}
+
+func (b *BDOS) rename(m Memory, fcb uint16) uint16 {
+	path, ok := b.lookup(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	name := fcbName(m, fcb+16)
+	if strings.ContainsRune(name, '?') {
+		return 0xFF
+	}
+	b.closeFile(b.fileKey(m, fcb))
+	if err := os.Rename(path, filepath.Join(filepath.Dir(path), hostName(name))); err != nil {
+		return 0xFF
+	}
+	return 0
+}
+
+// read record into DMA, 1 at end of file
+func (b *BDOS) readRecord(m Memory, fcb uint16, record int) uint16 {
+	f, ok := b.file(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	data := make([]byte, cpmRecordSize)
+	n, _ := f.ReadAt(data, int64(record)*cpmRecordSize)
+	if n == 0 {
+		return 1
+	}
+	for i := n; i < cpmRecordSize; i++ {
+		data[i] = 0x1A
+	}
+	writeBytes(m, b.dma, data)
+	return 0
+}
+
+// write record from DMA
+func (b *BDOS) writeRecord(m Memory, fcb uint16, record int) uint16 {
+	f, ok := b.file(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	data := make([]byte, cpmRecordSize)
+	for i := range data {
+		data[i] = m.Read(b.dma + uint16(i))
+	}
+	if _, err := f.WriteAt(data, int64(record)*cpmRecordSize); err != nil {
+		// disk full
+		return 2
+	}
+	return 0
+}
+
+func (b *BDOS) readSequential(m Memory, fcb uint16) uint16 {
+	record := fcbRecord(m, fcb)
+	result := b.readRecord(m, fcb, record)
+	if result != 0 {
+		return result
+	}
+	f, _ := b.file(m, fcb)
+	setFcbRecord(m, fcb, record+1, fileRecords(f))
+	return 0
+}
+
+func (b *BDOS) writeSequential(m Memory, fcb uint16) uint16 {
+	record := fcbRecord(m, fcb)
+	result := b.writeRecord(m, fcb, record)
+	if result != 0 {
+		return result
+	}
+	f, _ := b.file(m, fcb)
+	setFcbRecord(m, fcb, record+1, fileRecords(f))
+	return 0
+}
+
+// random access leaves the current record at the accessed record
+func (b *BDOS) readRandom(m Memory, fcb uint16) uint16 {
+	record, ok := randomRecord(m, fcb)
+	if !ok {
+		return 6
+	}
+	f, ok := b.file(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	setFcbRecord(m, fcb, record, fileRecords(f))
+	return b.readRecord(m, fcb, record)
+}
+
+func (b *BDOS) writeRandom(m Memory, fcb uint16) uint16 {
+	record, ok := randomRecord(m, fcb)
+	if !ok {
+		return 6
+	}
+	result := b.writeRecord(m, fcb, record)
+	if result != 0 {
+		return result
+	}
+	f, _ := b.file(m, fcb)
+	setFcbRecord(m, fcb, record, fileRecords(f))
+	return 0
+}
+
+func (b *BDOS) fileSize(m Memory, fcb uint16) uint16 {
+	f, ok := b.file(m, fcb)
+	if !ok {
+		return 0xFF
+	}
+	setRandomRecord(m, fcb, fileRecords(f))
+	return 0
+}
+
+func writeBytes(m Memory, address uint16, data []byte) {
+	for i, value := range data {
+		m.Write(address+uint16(i), value)
+	}
+}
@@ -0,0 +1,430 @@
+package gomu8080
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// data area of the BDOS test programs, FCB and FCB2 are filled by the test,
+// results of the calls are stored from RES on
+const bdosTestData = `
+FCB:	DS	36
+FCB2:	DS	36
+COUNT:	DS	2
+PTR:	DS	2
+RES:	DS	16
+LINE:	DS	16
+BUF:	DS	128
+DIR:	DS	128
+TABLE:	DS	256
+`
+
+// BDOS function C with DE, A is stored at RES+n
+func bdosCall(c int, de string, n int) string {
+	return fmt.Sprintf("\tMVI\tC,%d\n\tLXI\tD,%s\n\tCALL\t5\n\tSTA\tRES+%d\n", c, de, n)
+}
+
+type bdosRun struct {
+	mmu     *MMU
+	symbols map[string]uint16
+	out     string
+}
+
+// byte at symbol + offset
+func (r *bdosRun) byte(symbol string, offset int) byte {
+	return r.mmu.Memory[int(r.symbols[symbol])+offset]
+}
+
+func (r *bdosRun) result(n int) byte {
+	return r.byte("RES", n)
+}
+
+// run program at 0100H on the BDOS with the host directory dir until it
+// returns to warm boot, fcbs are the names of FCB and FCB2
+func runBDOS(t *testing.T, dir string, input string, fcbs []string, program string) *bdosRun {
+	t.Helper()
+	source := "\tORG\t100H\n" + program + "\tRET\n" + bdosTestData
+	prog, err := asm.Assemble(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mmu := NewMMU()
+	p := NewProcessor(mmu, false)
+	var out bytes.Buffer
+	b := NewBDOS(dir, strings.NewReader(input), &out)
+	if err := b.Boot(p, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer b.closeFiles()
+	b.Attach(p)
+	binary := prog.Binary()
+	mmu.Load(len(binary), binary, int(prog.Origin))
+	for i, name := range fcbs {
+		fcb := prog.Symbols[[]string{"FCB", "FCB2"}[i]]
+		writeBytes(mmu, fcb, parseFileName(name))
+	}
+	p.PC = prog.Entry
+	for steps := 0; !p.IsHalt; steps++ {
+		if steps > 10000000 {
+			t.Fatal("program does not return")
+		}
+		p.Run()
+	}
+	return &bdosRun{mmu, prog.Symbols, out.String()}
+}
+
+func writeHostFile(t *testing.T, dir string, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readHostFile(t *testing.T, dir string, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// n records, every byte of record i is i
+func records(n int) []byte {
+	data := make([]byte, n*cpmRecordSize)
+	for i := range data {
+		data[i] = byte(i / cpmRecordSize)
+	}
+	return data
+}
+
+func TestBDOSFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		fcbs    []string
+		program string
+		check   func(t *testing.T, dir string, r *bdosRun)
+	}{
+		{
+			name: "make write close",
+			fcbs: []string{"OUT.TXT"},
+			program: bdosCall(22, "FCB", 0) +
+				bdosCall(26, "BUF", 1) +
+				bdosCall(21, "FCB", 2) +
+				"\tLXI\tH,BUF\n\tINR\tM\n" +
+				bdosCall(21, "FCB", 3) +
+				bdosCall(16, "FCB", 4),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				for n, want := range []byte{0, 0, 0, 0, 0} {
+					if r.result(n) != want {
+						t.Errorf("result %d: got %02X, want %02X", n, r.result(n), want)
+					}
+				}
+				if got := readHostFile(t, dir, "OUT.TXT"); len(got) != 256 || got[0] != 0 || got[128] != 1 {
+					t.Errorf("file %X", got)
+				}
+				if r.byte("FCB", 32) != 2 || r.byte("FCB", 15) != 2 {
+					t.Errorf("cr %d rc %d, want 2 2", r.byte("FCB", 32), r.byte("FCB", 15))
+				}
+			},
+		},
+		{
+			name:  "open read sequential to end of file",
+			files: map[string][]byte{"IN.DAT": bytes.Repeat([]byte{'A'}, 200)},
+			fcbs:  []string{"IN.DAT"},
+			program: bdosCall(15, "FCB", 0) +
+				"\tLDA\tFCB+15\n\tSTA\tRES+1\n" +
+				bdosCall(26, "BUF", 2) +
+				bdosCall(20, "FCB", 3) +
+				bdosCall(20, "FCB", 4) +
+				bdosCall(20, "FCB", 5),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				// open, record count 2, two records, end of file
+				for n, want := range []byte{0, 2, 0, 0, 0, 1} {
+					if r.result(n) != want {
+						t.Errorf("result %d: got %02X, want %02X", n, r.result(n), want)
+					}
+				}
+				// the last record is padded with Ctrl-Z
+				if r.byte("BUF", 71) != 'A' || r.byte("BUF", 72) != 0x1A || r.byte("BUF", 127) != 0x1A {
+					t.Errorf("last record % X", r.mmu.Memory[r.symbols["BUF"]:r.symbols["BUF"]+128])
+				}
+			},
+		},
+		{
+			name:    "open missing file",
+			fcbs:    []string{"NONE.TXT"},
+			program: bdosCall(15, "FCB", 0) + bdosCall(16, "FCB", 1),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				if r.result(0) != 0xFF || r.result(1) != 0xFF {
+					t.Errorf("open %02X close %02X, want FF FF", r.result(0), r.result(1))
+				}
+			},
+		},
+		{
+			name:  "random read and write",
+			files: map[string][]byte{"RND.DAT": records(10)},
+			fcbs:  []string{"RND.DAT"},
+			program: bdosCall(15, "FCB", 0) +
+				bdosCall(26, "BUF", 1) +
+				"\tMVI\tA,7\n\tSTA\tFCB+33\n" +
+				bdosCall(33, "FCB", 2) +
+				"\tLDA\tBUF\n\tSTA\tRES+3\n" +
+				// record 300 of extent 2, past the end of the file
+				"\tLXI\tH,300\n\tSHLD\tFCB+33\n" +
+				bdosCall(34, "FCB", 4) +
+				bdosCall(35, "FCB", 5) +
+				// random record overflow
+				"\tMVI\tA,1\n\tSTA\tFCB+35\n" +
+				bdosCall(33, "FCB", 6),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				for n, want := range []byte{0, 0, 0, 7, 0, 0, 6} {
+					if r.result(n) != want {
+						t.Errorf("result %d: got %02X, want %02X", n, r.result(n), want)
+					}
+				}
+				// write random leaves the current record at the written one
+				if ex, cr := r.byte("FCB", 12), r.byte("FCB", 32); ex != 2 || cr != 44 {
+					t.Errorf("ex %d cr %d, want 2 44", ex, cr)
+				}
+				data := readHostFile(t, dir, "RND.DAT")
+				if len(data) != 301*cpmRecordSize || data[300*cpmRecordSize] != 7 {
+					t.Errorf("%d bytes", len(data))
+				}
+				// compute file size
+				if r.byte("FCB", 33) != byte(301&0xFF) || r.byte("FCB", 34) != byte(301>>8) {
+					t.Errorf("size %d", int(r.byte("FCB", 33))|int(r.byte("FCB", 34))<<8)
+				}
+			},
+		},
+		{
+			name:  "search first and next",
+			files: map[string][]byte{"B.TXT": nil, "A.TXT": make([]byte, 300), "C.COM": nil},
+			fcbs:  []string{"*.TXT"},
+			program: bdosCall(26, "DIR", 0) +
+				bdosCall(17, "FCB", 1) +
+				"\tLXI\tH,DIR\n\tLXI\tD,TABLE\n\tMVI\tB,32\nCOPY:\tMOV\tA,M\n\tSTAX\tD\n\tINX\tH\n\tINX\tD\n\tDCR\tB\n\tJNZ\tCOPY\n" +
+				bdosCall(18, "0", 2) +
+				bdosCall(18, "0", 3),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				for n, want := range []byte{0, 0, 0, 0xFF} {
+					if r.result(n) != want {
+						t.Errorf("result %d: got %02X, want %02X", n, r.result(n), want)
+					}
+				}
+				table := r.mmu.Memory[r.symbols["TABLE"]:]
+				if name := string(table[1:12]); name != "A       TXT" || table[15] != 3 {
+					t.Errorf("first entry %q rc %d, want A.TXT with 3 records", name, table[15])
+				}
+				if name := string(r.mmu.Memory[r.symbols["DIR"]+1 : r.symbols["DIR"]+12]); name != "B       TXT" {
+					t.Errorf("next entry %q, want B.TXT", name)
+				}
+			},
+		},
+		{
+			name:    "delete",
+			files:   map[string][]byte{"A.TXT": nil, "B.TXT": nil, "C.COM": nil},
+			fcbs:    []string{"*.TXT", "X.TXT"},
+			program: bdosCall(19, "FCB", 0) + bdosCall(19, "FCB2", 1),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				if r.result(0) != 0 || r.result(1) != 0xFF {
+					t.Errorf("delete %02X %02X, want 00 FF", r.result(0), r.result(1))
+				}
+				entries, _ := os.ReadDir(dir)
+				if len(entries) != 1 || entries[0].Name() != "C.COM" {
+					t.Errorf("left %v, want C.COM", entries)
+				}
+			},
+		},
+		{
+			name:    "rename",
+			files:   map[string][]byte{"OLD.TXT": []byte("data")},
+			fcbs:    []string{"OLD.TXT", "NEW.DOC"},
+			program: "\tLXI\tH,FCB2\n\tLXI\tD,FCB+16\n\tMVI\tB,16\nCOPY:\tMOV\tA,M\n\tSTAX\tD\n\tINX\tH\n\tINX\tD\n\tDCR\tB\n\tJNZ\tCOPY\n" + bdosCall(23, "FCB", 0),
+			check: func(t *testing.T, dir string, r *bdosRun) {
+				if r.result(0) != 0 {
+					t.Errorf("rename %02X", r.result(0))
+				}
+				if data := readHostFile(t, dir, "NEW.DOC"); string(data) != "data" {
+					t.Errorf("NEW.DOC %q", data)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "OLD.TXT")); err == nil {
+					t.Error("OLD.TXT kept")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				writeHostFile(t, dir, name, data)
+			}
+			r := runBDOS(t, dir, "", tt.fcbs, tt.program)
+			tt.check(t, dir, r)
+		})
+	}
+}
+
+// 200 records (25K) span two logical extents of 16K
+func TestBDOSMultiExtent(t *testing.T) {
+	const program = `
+	MVI	C,22
+	LXI	D,FCB
+	CALL	5
+	MVI	C,26
+	LXI	D,BUF
+	CALL	5
+	MVI	A,200
+	STA	COUNT
+WLOOP:	MVI	C,21
+	LXI	D,FCB
+	CALL	5
+	ORA	A
+	JNZ	FAIL
+	LXI	H,BUF
+	INR	M
+	LDA	COUNT
+	DCR	A
+	STA	COUNT
+	JNZ	WLOOP
+	LDA	FCB+12
+	STA	RES
+	LDA	FCB+32
+	STA	RES+1
+	MVI	C,16
+	LXI	D,FCB
+	CALL	5
+
+	; read back from the start
+	XRA	A
+	STA	FCB+12
+	STA	FCB+14
+	STA	FCB+32
+	MVI	C,15
+	LXI	D,FCB
+	CALL	5
+	LDA	FCB+15
+	STA	RES+2
+	LXI	H,TABLE
+	SHLD	PTR
+RLOOP:	MVI	C,20
+	LXI	D,FCB
+	CALL	5
+	ORA	A
+	JNZ	RDONE
+	LDA	BUF
+	LHLD	PTR
+	MOV	M,A
+	INX	H
+	SHLD	PTR
+	JMP	RLOOP
+RDONE:	STA	RES+3
+	LDA	FCB+12
+	STA	RES+4
+	LDA	FCB+15
+	STA	RES+5
+	MVI	C,35
+	LXI	D,FCB
+	CALL	5
+	RET
+FAIL:	STA	RES+15
+`
+	dir := t.TempDir()
+	r := runBDOS(t, dir, "", []string{"BIG.DAT"}, program)
+	if r.result(15) != 0 {
+		t.Fatalf("write failed with %02X", r.result(15))
+	}
+	// after 200 records - extent 1, record 72
+	// reopened - 128 records in extent 0, end of file in extent 1 with 72 records
+	for n, want := range []byte{1, 72, 128, 1, 1, 72} {
+		if r.result(n) != want {
+			t.Errorf("result %d: got %d, want %d", n, r.result(n), want)
+		}
+	}
+	// the program counts the first byte of the records up
+	want := make([]byte, 200*cpmRecordSize)
+	for i := range want {
+		if i%cpmRecordSize == 0 {
+			want[i] = byte(i / cpmRecordSize)
+		}
+	}
+	data := readHostFile(t, dir, "BIG.DAT")
+	if !bytes.Equal(data, want) {
+		t.Errorf("file of %d bytes differs", len(data))
+	}
+	if count := int(r.symbols["TABLE"]) + 200; int(r.byte("PTR", 0))|int(r.byte("PTR", 1))<<8 != count {
+		t.Errorf("read %d records, want 200", int(r.byte("PTR", 0))|int(r.byte("PTR", 1))<<8-int(r.symbols["TABLE"]))
+	}
+	for i := 0; i < 200; i++ {
+		if r.byte("TABLE", i) != byte(i) {
+			t.Fatalf("record %d read as %d", i, r.byte("TABLE", i))
+		}
+	}
+	if size := int(r.byte("FCB", 33)) | int(r.byte("FCB", 34))<<8; size != 200 {
+		t.Errorf("file size %d, want 200", size)
+	}
+}
+
+func TestFCBRecord(t *testing.T) {
+	tests := []struct {
+		record  int
+		records int
+		s2      byte
+		ex      byte
+		cr      byte
+		rc      byte
+	}{
+		{0, 0, 0, 0, 0, 0},
+		{5, 10, 0, 0, 5, 10},
+		{127, 300, 0, 0, 127, 128},
+		{128, 200, 0, 1, 0, 72},
+		{300, 301, 0, 2, 44, 45},
+		{4096, 5000, 1, 0, 0, 128},
+		{4096 + 129, 4200, 1, 1, 1, 0},
+		{4096 + 129, 4300, 1, 1, 1, 76},
+		{200, 100, 0, 1, 72, 0},
+	}
+	for _, tt := range tests {
+		m := NewMMU()
+		setFcbRecord(m, 0x5C, tt.record, tt.records)
+		s2, ex, cr, rc := m.Memory[0x5C+14], m.Memory[0x5C+12], m.Memory[0x5C+32], m.Memory[0x5C+15]
+		if s2 != tt.s2 || ex != tt.ex || cr != tt.cr || rc != tt.rc {
+			t.Errorf("record %d of %d: s2 %d ex %d cr %d rc %d, want %d %d %d %d",
+				tt.record, tt.records, s2, ex, cr, rc, tt.s2, tt.ex, tt.cr, tt.rc)
+		}
+		if got := fcbRecord(m, 0x5C); got != tt.record {
+			t.Errorf("fcbRecord %d, want %d", got, tt.record)
+		}
+	}
+}
+
+func TestBDOSReadLine(t *testing.T) {
+	tests := []struct {
+		input string
+		max   byte
+		want  string
+	}{
+		{"hello\n", 10, "hello"},
+		{"hello world\n", 5, "hello"},
+		{"a\r\nb\n", 10, "a"},
+		{"\n", 10, ""},
+		{"end of input", 20, "end of input"},
+	}
+	for _, tt := range tests {
+		program := fmt.Sprintf("\tMVI\tA,%d\n\tSTA\tLINE\n", tt.max) + bdosCall(10, "LINE", 0)
+		r := runBDOS(t, t.TempDir(), tt.input, nil, program)
+		count := r.byte("LINE", 1)
+		line := r.mmu.Memory[int(r.symbols["LINE"])+2 : int(r.symbols["LINE"])+2+int(count)]
+		if string(line) != tt.want {
+			t.Errorf("%q max %d: got %q, want %q", tt.input, tt.max, line, tt.want)
+		}
+	}
+}
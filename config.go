@@ -0,0 +1,92 @@
+package gomu8080
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config - settings of Space Invaders read from a JSON file
+//
+//	{
+//	  "dip_switches": {"ships": 5, "extra_ship_at": 1000, "coin_info": false},
+//	  "keys": {"p1_fire": ["Space", "ControlLeft"], "coin": ["Digit5"]},
+//	  "gamepad": {"fire": ["RightBottom", "RightRight"]}
+//	}
+//
+// keys maps inputs to ebiten key names, gamepad maps the player actions
+// coin, start, fire, left and right to buttons of the standard layout,
+// the first gamepad plays player 1 and the second player 2,
+// mappings missing from the file keep their defaults
+type Config struct {
+	DipSwitches DipSwitches         `json:"dip_switches"`
+	Keys        map[string][]string `json:"keys"`
+	Gamepad     map[string][]string `json:"gamepad"`
+}
+
+// GamepadActions - player actions of the gamepad mapping
+var GamepadActions = []string{"coin", "start", "fire", "left", "right"}
+
+func DefaultConfig() *Config {
+	return &Config{
+		DipSwitches: DipSwitches{Ships: 3, ExtraShipAt: 1500, CoinInfo: true},
+		Keys: map[string][]string{
+			"coin":     {"Enter"},
+			"tilt":     {"T"},
+			"p1_start": {"P"},
+			"p2_start": {"O"},
+			"p1_fire":  {"Space"},
+			"p1_left":  {"ArrowLeft"},
+			"p1_right": {"ArrowRight"},
+			"p2_fire":  {"W"},
+			"p2_left":  {"A"},
+			"p2_right": {"D"},
+		},
+		Gamepad: map[string][]string{
+			"coin":  {"CenterLeft"},
+			"start": {"CenterRight"},
+			"fire":  {"RightBottom"},
+			"left":  {"LeftLeft"},
+			"right": {"LeftRight"},
+		},
+	}
+}
+
+// LoadConfig - read config file over the defaults
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("Config: Load: Error: %v", err)
+	}
+	for name := range config.Keys {
+		if _, err := ParseInput(name); err != nil {
+			return nil, err
+		}
+	}
+	for name := range config.Gamepad {
+		if _, err := PlayerInput(1, name); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// PlayerInput - input of a gamepad action for player 1 or 2
+func PlayerInput(player int, action string) (Input, error) {
+	if player != 1 && player != 2 {
+		return 0, fmt.Errorf("Config: Error: invalid player %d", player)
+	}
+	switch action {
+	case "coin":
+		return InputCoin, nil
+	case "start":
+		return ParseInput(fmt.Sprintf("p%d_start", player))
+	case "fire", "left", "right":
+		return ParseInput(fmt.Sprintf("p%d_%s", player, action))
+	}
+	return 0, fmt.Errorf("Config: Error: unknown gamepad action %q", action)
+}
@@ -0,0 +1,96 @@
+package gomu8080
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// console I/O ports
+const (
+	ConsoleStatusPort = 0x00 // in - 0xFF when a character is ready
+	ConsoleDataPort   = 0x01 // in - read character, out - write character
+)
+
+// Console - terminal of the emulated machine
+// input is read in background so the status can be polled without blocking,
+// it is line buffered (and echoed) by the host terminal
+type Console struct {
+	in    *bufio.Reader
+	out   io.Writer
+	input chan byte
+}
+
+func NewConsole(in io.Reader, out io.Writer) *Console {
+	c := &Console{}
+	c.in = bufio.NewReader(in)
+	c.out = out
+	return c
+}
+
+// start reading input in background on first use
+func (c *Console) start() {
+	if c.input != nil {
+		return
+	}
+	c.input = make(chan byte, 256)
+	go func() {
+		for {
+			b, err := c.in.ReadByte()
+			if err != nil {
+				// end of input reads as CP/M end of file (Ctrl-Z)
+				c.input <- 0x1A
+				close(c.input)
+				return
+			}
+			c.input <- b
+		}
+	}()
+}
+
+// Ready - a character can be read without blocking
+func (c *Console) Ready() bool {
+	c.start()
+	return len(c.input) > 0
+}
+
+// Read - read one character, 0x1A after end of input
+func (c *Console) Read() byte {
+	c.start()
+	b, ok := <-c.input
+	if !ok {
+		return 0x1A
+	}
+	return b
+}
+
+// ReadChar - read one character, new line is returned as carriage return
+func (c *Console) ReadChar() byte {
+	b := c.Read()
+	if b == '\n' {
+		return '\r'
+	}
+	return b
+}
+
+func (c *Console) Write(b byte) {
+	fmt.Fprintf(c.out, "%c", b)
+}
+
+func (c *Console) In(port byte) byte {
+	switch port {
+	case ConsoleStatusPort:
+		if c.Ready() {
+			return 0xFF
+		}
+	case ConsoleDataPort:
+		return c.ReadChar()
+	}
+	return 0
+}
+
+func (c *Console) Out(port byte, data byte) {
+	if port == ConsoleDataPort {
+		c.Write(data)
+	}
+}
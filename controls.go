@@ -0,0 +1,232 @@
+package gomu8080
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Input - button of the Space Invaders cabinet
+type Input int
+
+const (
+	InputCoin Input = iota
+	InputTilt
+	InputP1Start
+	InputP2Start
+	InputP1Fire
+	InputP1Left
+	InputP1Right
+	InputP2Fire
+	InputP2Left
+	InputP2Right
+	InputCount
+)
+
+// names of the inputs in the config file
+var inputNames = [InputCount]string{
+	"coin", "tilt", "p1_start", "p2_start",
+	"p1_fire", "p1_left", "p1_right",
+	"p2_fire", "p2_left", "p2_right",
+}
+
+func (i Input) String() string {
+	if i < 0 || i >= InputCount {
+		return fmt.Sprintf("input %d", int(i))
+	}
+	return inputNames[i]
+}
+
+// ParseInput - input of a config name
+func ParseInput(name string) (Input, error) {
+	for i, n := range inputNames {
+		if n == name {
+			return Input(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Controls: Error: unknown input %q", name)
+}
+
+// DipSwitches - settings of the dip switches
+type DipSwitches struct {
+	Ships       int  `json:"ships"`         // 3 - 6
+	ExtraShipAt int  `json:"extra_ship_at"` // 1000 or 1500 points
+	CoinInfo    bool `json:"coin_info"`     // coin info displayed in demo screen
+}
+
+// Controls - player inputs and dip switches on port 0, 1 and 2
+type Controls struct {
+	// Dips switch
+	dip4 bool // self-test-request read at power up
+	dip3 bool // 00 = 3 ships  10 = 5 ships
+	dip5 bool // 01 = 4 ships  11 = 6 ships
+	dip6 bool // extra ship at 1500, 1 = extra ship at 1000
+	dip7 bool // Coin info displayed in demo screen 0=ON
+
+	pressed [InputCount]bool
+}
+
+func NewControls() *Controls {
+	c := &Controls{}
+	c.dip4 = true
+	return c
+}
+
+// Press - set whether input is held down
+func (c *Controls) Press(input Input, pressed bool) {
+	if input < 0 || input >= InputCount {
+		return
+	}
+	c.pressed[input] = pressed
+}
+
+// Pressed - whether input is held down
+func (c *Controls) Pressed(input Input) bool {
+	if input < 0 || input >= InputCount {
+		return false
+	}
+	return c.pressed[input]
+}
+
+// ReleaseAll - release every input
+func (c *Controls) ReleaseAll() {
+	c.pressed = [InputCount]bool{}
+}
+
+// InputState - held inputs, bit n is Input n
+func (c *Controls) InputState() uint16 {
+	state := uint16(0)
+	for input, pressed := range c.pressed {
+		if pressed {
+			state |= 1 << input
+		}
+	}
+	return state
+}
+
+// SetInputState - hold the inputs of state and release all others
+func (c *Controls) SetInputState(state uint16) {
+	for input := range c.pressed {
+		c.pressed[input] = state&(1<<input) != 0
+	}
+}
+
+func (c *Controls) SetDipSwitches(d DipSwitches) error {
+	if d.Ships < 3 || d.Ships > 6 {
+		return errors.New("Controls: SetDipSwitches: Error: ships must be 3 - 6")
+	}
+	if d.ExtraShipAt != 1000 && d.ExtraShipAt != 1500 {
+		return errors.New("Controls: SetDipSwitches: Error: extra ship must be at 1000 or 1500")
+	}
+	ships := d.Ships - 3
+	c.dip3 = ships&0x1 > 0
+	c.dip5 = ships&0x2 > 0
+	c.dip6 = d.ExtraShipAt == 1000
+	c.dip7 = !d.CoinInfo
+	return nil
+}
+
+func (c *Controls) DipSwitches() DipSwitches {
+	d := DipSwitches{Ships: 3, ExtraShipAt: 1500, CoinInfo: !c.dip7}
+	if c.dip3 {
+		d.Ships += 1
+	}
+	if c.dip5 {
+		d.Ships += 2
+	}
+	if c.dip6 {
+		d.ExtraShipAt = 1000
+	}
+	return d
+}
+
+func (c *Controls) In(port byte) byte {
+	switch port {
+	case 0:
+		data := uint8(0b10001110)
+
+		// bit 0 - self test
+		if c.dip4 {
+			data |= 0x1
+		}
+		// bit 4 - fire
+		if c.pressed[InputP1Fire] {
+			data |= (0x1 << 4)
+		}
+		// bit 5 - left
+		if c.pressed[InputP1Left] {
+			data |= (0x1 << 5)
+		}
+		// bit 6 - right
+		if c.pressed[InputP1Right] {
+			data |= (0x1 << 6)
+		}
+		return data
+
+	case 1:
+		data := uint8(0b00001000)
+
+		// bit 0 - deposit a credit
+		if c.pressed[InputCoin] {
+			data |= 0x1
+		}
+
+		// bit 1 - 2p start
+		if c.pressed[InputP2Start] {
+			data |= (0x1 << 1)
+		}
+		// bit 2 - 1p start
+		if c.pressed[InputP1Start] {
+			data |= (0x1 << 2)
+		}
+		// bit 4 - fire (1p)
+		if c.pressed[InputP1Fire] {
+			data |= (0x1 << 4)
+		}
+		// bit 5 - left (1p)
+		if c.pressed[InputP1Left] {
+			data |= (0x1 << 5)
+		}
+		// bit 6 - right (1p)
+		if c.pressed[InputP1Right] {
+			data |= (0x1 << 6)
+		}
+		return data
+
+	case 2:
+		data := uint8(0)
+		// bit 0 - ship
+		if c.dip3 {
+			data |= 0x1
+		}
+		// bit 1 - ship
+		if c.dip5 {
+			data |= (0x1 << 1)
+		}
+		// bit 2 - tilt
+		if c.pressed[InputTilt] {
+			data |= (0x1 << 2)
+		}
+		// bit 3 - extra ship
+		if c.dip6 {
+			data |= (0x1 << 3)
+		}
+		// bit 4 - fire (2p)
+		if c.pressed[InputP2Fire] {
+			data |= (0x1 << 4)
+		}
+		// bit 5 - left (2p)
+		if c.pressed[InputP2Left] {
+			data |= (0x1 << 5)
+		}
+		// bit 6 - right (2p)
+		if c.pressed[InputP2Right] {
+			data |= (0x1 << 6)
+		}
+		// bit 7 - coin info displayed on screen
+		if c.dip7 {
+			data |= (0x1 << 7)
+		}
+		return data
+	}
+	return 0
+}
@@ -0,0 +1,292 @@
+package gomu8080
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// memory layout of 64K CP/M 2.2
+const (
+	CCPBase    = 0xE400
+	BIOSOffset = 0x1600 // CCP (2K) and BDOS (3.5K)
+)
+
+// CP/M 2.2 BIOS, console and disk controller on I/O ports, IBM 3740 disks A-D
+// cold and warm boot load CCP and BDOS from the system tracks of drive A
+const cpmBIOSSource = `
+BDOS    EQU     CCP+0806H
+NSECTS  EQU     (BIOS-CCP)/128  ; CCP and BDOS sectors on the system tracks
+IOBYTE  EQU     0003H
+CDISK   EQU     0004H
+NDISKS  EQU     4
+
+        ORG     BIOS
+        JMP     BOOT
+WBOOTE: JMP     WBOOT
+        JMP     CONST
+        JMP     CONIN
+        JMP     CONOUT
+        JMP     LIST
+        JMP     PUNCH
+        JMP     READER
+        JMP     HOME
+        JMP     SELDSK
+        JMP     SETTRK
+        JMP     SETSEC
+        JMP     SETDMA
+        JMP     READ
+        JMP     WRITE
+        JMP     LISTST
+        JMP     SECTRAN
+
+BOOT:   LXI     SP,80H
+        LXI     H,SIGNON
+        CALL    PRMSG
+        XRA     A
+        STA     IOBYTE
+        STA     CDISK
+        JMP     LOAD
+
+WBOOT:  LXI     SP,80H
+LOAD:   MVI     C,0
+        CALL    SELDSK
+        LXI     H,CCP           ; load address
+        MVI     B,NSECTS
+        MVI     D,0             ; track
+        MVI     E,2             ; sector after the cold start loader
+LOAD1:  MOV     A,D
+        OUT     TRKPORT
+        MOV     A,E
+        OUT     SECPORT
+        MOV     A,L
+        OUT     DMALPORT
+        MOV     A,H
+        OUT     DMAHPORT
+        XRA     A
+        OUT     CMDPORT
+        IN      STPORT
+        ORA     A
+        JNZ     LOADERR
+        PUSH    D
+        LXI     D,128
+        DAD     D
+        POP     D
+        INR     E
+        MOV     A,E
+        CPI     27
+        JC      LOAD2
+        MVI     E,1             ; next track
+        INR     D
+LOAD2:  DCR     B
+        JNZ     LOAD1
+
+        MVI     A,0C3H          ; JMP WBOOT at 0000H
+        STA     0
+        LXI     H,WBOOTE
+        SHLD    1
+        STA     5               ; JMP BDOS at 0005H
+        LXI     H,BDOS
+        SHLD    6
+        LXI     B,80H
+        CALL    SETDMA
+        LDA     CDISK
+        MOV     C,A
+        JMP     CCP
+
+LOADERR: LXI    H,LOADMSG
+        CALL    PRMSG
+        DI
+        HLT
+
+; print message at HL terminated by 0
+PRMSG:  MOV     A,M
+        ORA     A
+        RZ
+        OUT     CONDATA
+        INX     H
+        JMP     PRMSG
+
+CONST:  IN      CONSTAT
+        RET
+CONIN:  IN      CONDATA
+        ANI     7FH
+        RET
+CONOUT: MOV     A,C
+        OUT     CONDATA
+        RET
+LIST:
+PUNCH:  RET
+LISTST: XRA     A
+        RET
+READER: MVI     A,1AH
+        RET
+
+HOME:   MVI     C,0
+SETTRK: MOV     A,C
+        OUT     TRKPORT
+        RET
+SETSEC: MOV     A,C
+        OUT     SECPORT
+        RET
+SETDMA: MOV     A,C
+        OUT     DMALPORT
+        MOV     A,B
+        OUT     DMAHPORT
+        RET
+
+; disk parameter header of drive C in HL, 0 when there is no disk
+SELDSK: LXI     H,0
+        MOV     A,C
+        CPI     NDISKS
+        RNC
+        OUT     DRVPORT
+        IN      STPORT
+        ORA     A
+        RNZ
+        MOV     L,C
+        DAD     H
+        DAD     H
+        DAD     H
+        DAD     H
+        LXI     D,DPBASE
+        DAD     D
+        RET
+
+READ:   XRA     A
+        OUT     CMDPORT
+        IN      STPORT
+        RET
+WRITE:  MVI     A,1
+        OUT     CMDPORT
+        IN      STPORT
+        RET
+
+; physical sector in HL of logical sector BC with table DE
+SECTRAN: XCHG
+        DAD     B
+        MOV     L,M
+        MVI     H,0
+        RET
+
+SIGNON: DB      0DH,0AH,'CP/M 2.2 - gomu8080 BIOS',0DH,0AH,0
+LOADMSG: DB     0DH,0AH,'BOOT ERROR',0DH,0AH,0
+
+DPBASE: DW      XLT,0,0,0,DIRBUF,DPB,CSV0,ALV0
+        DW      XLT,0,0,0,DIRBUF,DPB,CSV1,ALV1
+        DW      XLT,0,0,0,DIRBUF,DPB,CSV2,ALV2
+        DW      XLT,0,0,0,DIRBUF,DPB,CSV3,ALV3
+
+; standard skew of IBM 3740
+XLT:    DB      1,7,13,19,25,5,11,17,23,3,9,15,21
+        DB      2,8,14,20,26,6,12,18,24,4,10,16,22
+
+DPB:    DW      26              ; sectors per track
+        DB      3               ; block shift
+        DB      7               ; block mask
+        DB      0               ; extent mask
+        DW      242             ; disk size - 1
+        DW      63              ; directory max
+        DB      0C0H            ; alloc 0
+        DB      0               ; alloc 1
+        DW      16              ; check size
+        DW      2               ; track offset
+
+DIRBUF: DS      128
+ALV0:   DS      31
+CSV0:   DS      16
+ALV1:   DS      31
+CSV1:   DS      16
+ALV2:   DS      31
+CSV2:   DS      16
+ALV3:   DS      31
+CSV3:   DS      16
+BIOSEND EQU     $
+        END
+`
+
+// CPM - genuine CP/M 2.2 booted from IBM 3740 disk images
+// with an emulated BIOS, the BDOS runs natively
+type CPM struct {
+	processor *Processor
+	memory    Memory
+	bus       *IOBus
+
+	Console *Console
+	Disk    *DiskController
+
+	// load address of CCP, BDOS follows at +0x800 and the BIOS at +0x1600
+	CCPBase uint16
+}
+
+func NewCPM(p *Processor, memory Memory, in io.Reader, out io.Writer) *CPM {
+	c := &CPM{}
+	c.processor = p
+	c.memory = memory
+	c.CCPBase = CCPBase
+	c.Console = NewConsole(in, out)
+	c.Disk = NewDiskController(memory)
+
+	c.bus = NewIOBus()
+	c.bus.AttachInput(ConsoleStatusPort, c.Console)
+	c.bus.AttachInput(ConsoleDataPort, c.Console)
+	c.bus.AttachOutput(ConsoleDataPort, c.Console)
+	for _, port := range []byte{DiskDrivePort, DiskTrackPort, DiskSectorPort, DiskCommandPort, DiskDMALowPort, DiskDMAHighPort} {
+		c.bus.AttachOutput(port, c.Disk)
+	}
+	c.bus.AttachInput(DiskStatusPort, c.Disk)
+	p.SetIO(c.bus)
+	return c
+}
+
+// Insert - put disk image into drive (0 = A)
+func (c *CPM) Insert(drive int, path string) error {
+	if drive < 0 || drive >= len(c.Disk.Drives) {
+		return errors.New("CPM: Insert: Error: invalid drive")
+	}
+	image, err := OpenDiskImage(path)
+	if err != nil {
+		return err
+	}
+	if old := c.Disk.Drives[drive]; old != nil {
+		old.Close()
+	}
+	c.Disk.Drives[drive] = image
+	return nil
+}
+
+// Boot - load the BIOS and start its cold boot
+func (c *CPM) Boot() error {
+	if c.Disk.Drives[0] == nil {
+		return errors.New("CPM: Boot: Error: no disk in drive A")
+	}
+	bios := c.CCPBase + BIOSOffset
+	header := fmt.Sprintf(`CCP EQU 0%04XH
+BIOS EQU 0%04XH
+CONSTAT EQU %d
+CONDATA EQU %d
+DRVPORT EQU %d
+TRKPORT EQU %d
+SECPORT EQU %d
+CMDPORT EQU %d
+STPORT EQU %d
+DMALPORT EQU %d
+DMAHPORT EQU %d
+`, c.CCPBase, bios, ConsoleStatusPort, ConsoleDataPort,
+		DiskDrivePort, DiskTrackPort, DiskSectorPort, DiskCommandPort, DiskStatusPort, DiskDMALowPort, DiskDMAHighPort)
+
+	program, err := asm.Assemble(header + cpmBIOSSource)
+	if err != nil {
+		return err
+	}
+	if program.Symbols["BIOSEND"] < bios {
+		return errors.New("CPM: Boot: Error: BIOS does not fit below 64K")
+	}
+	for i, data := range program.Binary() {
+		c.memory.Write(program.Origin+uint16(i), data)
+	}
+	c.processor.PC = bios
+	return nil
+}
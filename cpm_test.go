@@ -0,0 +1,171 @@
+package gomu8080
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// stand-in for the CCP, prints OK and goes through the BIOS entries on the
+// first start, halts when it is started again by the warm boot
+const testCCP = `
+BIOS	EQU	0FA00H
+	ORG	0E400H
+	MOV	A,C
+	STA	DRV
+	LXI	H,0
+	DAD	SP
+	SHLD	STACK
+	LDA	COUNT
+	INR	A
+	STA	COUNT
+	CPI	2
+	JZ	SECOND
+	MVI	C,'O'
+	CALL	BIOS+12
+	MVI	C,'K'
+	CALL	BIOS+12
+	; read track 2 logical sector 0 through SECTRAN
+	MVI	C,0
+	CALL	BIOS+27
+	SHLD	DPH
+	MVI	C,2
+	CALL	BIOS+30
+	LXI	B,0
+	LHLD	DPH
+	MOV	E,M
+	INX	H
+	MOV	D,M
+	CALL	BIOS+48
+	SHLD	PHYS
+	MOV	C,L
+	CALL	BIOS+33
+	LXI	B,8000H
+	CALL	BIOS+36
+	CALL	BIOS+39
+	STA	RES
+	; write it to track 3 sector 1
+	MVI	C,3
+	CALL	BIOS+30
+	MVI	C,1
+	CALL	BIOS+33
+	CALL	BIOS+42
+	STA	RES+1
+	; select missing drive B
+	MVI	C,1
+	CALL	BIOS+27
+	SHLD	RES+2
+	JMP	0
+SECOND:	DI
+	HLT
+DPH	EQU	8100H
+PHYS	EQU	8102H
+DRV	EQU	8104H
+STACK	EQU	8106H
+COUNT	EQU	0040H
+RES	EQU	8110H
+`
+
+func TestCPMBoot(t *testing.T) {
+	ccp, err := asm.Assemble(testCCP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// short image, the CCP follows the cold start loader in sector 1
+	image := make([]byte, 200*DiskSectorSize)
+	copy(image[DiskSectorSize:], ccp.Binary())
+	// track 2, physical sector 1 is logical sector 0 with the skew
+	copy(image[2*DiskSectors*DiskSectorSize:], bytes.Repeat([]byte("Z"), DiskSectorSize))
+	path := filepath.Join(t.TempDir(), "a.img")
+	if err := os.WriteFile(path, image, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mmu := NewMMU()
+	p := NewProcessor(mmu, false)
+	var out bytes.Buffer
+	c := NewCPM(p, mmu, strings.NewReader(""), &out)
+	if err := c.Insert(0, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Boot(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 17 entries of the jump table, the first one is the cold boot
+	bios := uint16(CCPBase + BIOSOffset)
+	if p.PC != bios {
+		t.Errorf("PC %04X, want %04X", p.PC, bios)
+	}
+	for i := uint16(0); i < 17; i++ {
+		entry := bios + 3*i
+		target := uint16(mmu.Memory[entry+1]) | uint16(mmu.Memory[entry+2])<<8
+		if mmu.Memory[entry] != 0xC3 || target <= bios+3*16 || target >= bios+0x200 {
+			t.Errorf("entry %d: % X", i, mmu.Memory[entry:entry+3])
+		}
+	}
+
+	for n := 0; !p.IsHalt; n++ {
+		if n > 1000000 {
+			t.Fatal("CCP was not started twice")
+		}
+		p.Run()
+	}
+
+	if !strings.HasPrefix(out.String(), "\r\nCP/M 2.2") || !strings.HasSuffix(out.String(), "OK") {
+		t.Errorf("console %q", out.String())
+	}
+	// page zero - JMP WBOOTE and JMP BDOS
+	if !bytes.Equal(mmu.Memory[0:3], []byte{0xC3, byte(bios + 3), byte((bios + 3) >> 8)}) {
+		t.Errorf("warm boot jump % X", mmu.Memory[0:3])
+	}
+	bdos := uint16(CCPBase + 0x806)
+	if !bytes.Equal(mmu.Memory[5:8], []byte{0xC3, byte(bdos), byte(bdos >> 8)}) {
+		t.Errorf("BDOS jump % X", mmu.Memory[5:8])
+	}
+	// the CCP is entered with drive A in C and the BIOS stack
+	symbols := ccp.Symbols
+	if drive := mmu.Memory[symbols["DRV"]]; drive != 0 {
+		t.Errorf("CCP entered with drive %d", drive)
+	}
+	if stack := uint16(mmu.Memory[symbols["STACK"]]) | uint16(mmu.Memory[symbols["STACK"]+1])<<8; stack != 0x80 {
+		t.Errorf("CCP entered with SP %04X", stack)
+	}
+
+	res := mmu.Memory[symbols["RES"]:]
+	if phys := mmu.Memory[symbols["PHYS"]]; phys != 1 {
+		t.Errorf("SECTRAN of logical sector 0: %d, want 1", phys)
+	}
+	if res[0] != 0 || res[1] != 0 {
+		t.Errorf("read status %d write status %d", res[0], res[1])
+	}
+	if res[2] != 0 || res[3] != 0 {
+		t.Errorf("SELDSK of missing drive B: %02X%02X, want 0000", res[3], res[2])
+	}
+	if mmu.Memory[0x8000] != 'Z' {
+		t.Errorf("read % X", mmu.Memory[0x8000:0x8010])
+	}
+	if mmu.Memory[symbols["COUNT"]] != 2 {
+		t.Error("warm boot did not reload the CCP")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := 3 * DiskSectors * DiskSectorSize
+	if len(data) < offset+DiskSectorSize || data[offset] != 'Z' {
+		t.Error("sector was not written to track 3")
+	}
+}
+
+func TestCPMBootWithoutDisk(t *testing.T) {
+	mmu := NewMMU()
+	c := NewCPM(NewProcessor(mmu, false), mmu, strings.NewReader(""), &bytes.Buffer{})
+	if err := c.Boot(); err == nil {
+		t.Error("booted without a disk in drive A")
+	}
+}
@@ -0,0 +1,510 @@
+package gomu8080
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/detohm/gomu8080/disasm"
+)
+
+// DebugHook - debugger driven by the machine loop (Debugger or GDBStub)
+type DebugHook interface {
+	// BeforeStep - called before each instruction, returns false to quit
+	BeforeStep() bool
+	// Break - stop at the next instruction, safe from other goroutines
+	Break()
+}
+
+// Debugger - interactive command-line debugger
+// BeforeStep must be called before each instruction by the machine loop,
+// it runs the command prompt whenever the processor should stop.
+type Debugger struct {
+	processor *Processor
+	in        *bufio.Scanner
+	out       io.Writer
+
+	breakpoints map[uint16]bool
+
+	// stop after this number of instructions, 0 means run freely
+	steps int
+	// temporary breakpoint used by next (step over)
+	stepOver    uint16
+	hasStepOver bool
+
+	// a watchpoint was hit by the last instruction
+	watchHit bool
+
+	// stop requested from another goroutine (e.g. Ctrl-C or game window)
+	breakRequest atomic.Bool
+
+	// debugger session has ended
+	Quit bool
+}
+
+func NewDebugger(p *Processor, in io.Reader, out io.Writer) *Debugger {
+	d := &Debugger{}
+	d.processor = p
+	d.in = bufio.NewScanner(in)
+	d.out = out
+	d.breakpoints = map[uint16]bool{}
+	// stop at the first instruction
+	d.steps = 1
+	return d
+}
+
+// Break - request to stop at the next instruction, safe from other goroutines
+func (d *Debugger) Break() {
+	d.breakRequest.Store(true)
+}
+
+// BeforeStep - stop and prompt when a breakpoint, step or break request is hit
+// returns false when the user quits the debugger
+func (d *Debugger) BeforeStep() bool {
+	if d.Quit {
+		return false
+	}
+	pc := d.processor.PC
+	stop := false
+
+	if d.steps > 0 {
+		d.steps--
+		stop = d.steps == 0
+	}
+	if d.hasStepOver && pc == d.stepOver {
+		d.hasStepOver = false
+		stop = true
+	}
+	if d.breakpoints[pc] {
+		fmt.Fprintf(d.out, "breakpoint at %04X\n", pc)
+		stop = true
+	}
+	if d.watchHit {
+		d.watchHit = false
+		stop = true
+	}
+	if d.breakRequest.Swap(false) {
+		stop = true
+	}
+
+	if stop {
+		d.steps = 0
+		d.hasStepOver = false
+		d.prompt()
+	}
+	return !d.Quit
+}
+
+// read and execute commands until execution resumes
+func (d *Debugger) prompt() {
+	d.printRegisters()
+	d.printInstruction(d.processor.PC)
+	for {
+		fmt.Fprint(d.out, "(dbg) ")
+		if !d.in.Scan() {
+			d.Quit = true
+			return
+		}
+		args := strings.Fields(d.in.Text())
+		if len(args) == 0 {
+			continue
+		}
+		if d.command(args) {
+			return
+		}
+	}
+}
+
+// execute one command, returns true when execution resumes
+func (d *Debugger) command(args []string) bool {
+	p := d.processor
+	switch strings.ToLower(args[0]) {
+	case "s", "step":
+		d.steps = 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fmt.Fprintln(d.out, "invalid step count")
+				return false
+			}
+			d.steps = n
+		}
+		return true
+
+	case "n", "next":
+		inst := disasm.Disassemble(p.memory, p.PC)
+		if isCall(inst.Bytes[0]) {
+			d.stepOver = p.PC + uint16(inst.Length)
+			d.hasStepOver = true
+			return true
+		}
+		d.steps = 1
+		return true
+
+	case "c", "continue":
+		return true
+
+	case "bs", "back":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				fmt.Fprintln(d.out, "invalid step count")
+				return false
+			}
+		}
+		steps := p.RunBack(func(p *Processor) bool {
+			n--
+			return n == 0
+		})
+		d.watchHit = false
+		d.reverseStopped(steps)
+
+	case "rc", "rcontinue":
+		steps := p.RunBack(func(p *Processor) bool {
+			return d.breakpoints[p.PC] || d.watchHit
+		})
+		d.watchHit = false
+		d.reverseStopped(steps)
+
+	case "wa", "watch":
+		if len(args) < 2 {
+			d.listWatchpoints()
+			return false
+		}
+		kind := WatchWrite
+		if len(args) > 2 {
+			var ok bool
+			if kind, ok = ParseWatchKind(args[1]); !ok {
+				fmt.Fprintf(d.out, "invalid access %s, use r, w and x\n", args[1])
+				return false
+			}
+		}
+		start, end, ok := d.parseRange(args[len(args)-1])
+		if ok {
+			p.Watch(start, end, kind, d.onWatch)
+		}
+
+	case "uw", "unwatch":
+		if len(args) < 2 {
+			for _, w := range p.Watchpoints() {
+				p.Watch(w.Start, w.End, w.Kind, nil)
+			}
+			return false
+		}
+		// without access letters the watchpoints of every kind are deleted
+		kind := WatchKind(0)
+		if len(args) > 2 {
+			var ok bool
+			if kind, ok = ParseWatchKind(args[1]); !ok {
+				fmt.Fprintf(d.out, "invalid access %s, use r, w and x\n", args[1])
+				return false
+			}
+		}
+		start, end, ok := d.parseRange(args[len(args)-1])
+		if ok {
+			p.Watch(start, end, kind, nil)
+		}
+
+	case "b", "break":
+		if len(args) < 2 {
+			d.listBreakpoints()
+			return false
+		}
+		address, ok := d.parseValue(args[1])
+		if ok {
+			d.breakpoints[address] = true
+		}
+
+	case "d", "delete":
+		if len(args) < 2 {
+			d.breakpoints = map[uint16]bool{}
+			return false
+		}
+		address, ok := d.parseValue(args[1])
+		if ok {
+			delete(d.breakpoints, address)
+		}
+
+	case "r", "regs":
+		d.printRegisters()
+
+	case "set":
+		if len(args) != 3 {
+			fmt.Fprintln(d.out, "usage: set <register|flag> <value>")
+			return false
+		}
+		value, ok := d.parseValue(args[2])
+		if ok {
+			d.setRegister(args[1], value)
+		}
+
+	case "m", "mem":
+		if len(args) < 2 {
+			fmt.Fprintln(d.out, "usage: m <address> [length]")
+			return false
+		}
+		address, ok := d.parseValue(args[1])
+		length := uint16(0x40)
+		if len(args) > 2 {
+			var lok bool
+			length, lok = d.parseValue(args[2])
+			ok = ok && lok
+		}
+		if ok {
+			d.dumpMemory(address, int(length))
+		}
+
+	case "w", "write":
+		if len(args) < 3 {
+			fmt.Fprintln(d.out, "usage: w <address> <byte> [byte...]")
+			return false
+		}
+		address, ok := d.parseValue(args[1])
+		if !ok {
+			return false
+		}
+		for i, arg := range args[2:] {
+			value, ok := d.parseValue(arg)
+			if !ok || value > 0xFF {
+				fmt.Fprintf(d.out, "invalid byte %s\n", arg)
+				return false
+			}
+			p.memory.Write(address+uint16(i), byte(value))
+		}
+		p.ClearUndo()
+
+	case "l", "list":
+		address := p.PC
+		count := 10
+		if len(args) > 1 {
+			var ok bool
+			if address, ok = d.parseValue(args[1]); !ok {
+				return false
+			}
+		} else {
+			// show a few instructions before PC as well
+			address = d.findStart(p.PC)
+		}
+		if len(args) > 2 {
+			n, err := strconv.Atoi(args[2])
+			if err != nil || n < 1 {
+				fmt.Fprintln(d.out, "invalid count")
+				return false
+			}
+			count = n
+		}
+		for i := 0; i < count; i++ {
+			address += uint16(d.printInstruction(address))
+		}
+
+	case "q", "quit":
+		d.Quit = true
+		return true
+
+	case "h", "help", "?":
+		fmt.Fprint(d.out, debuggerHelp)
+
+	default:
+		fmt.Fprintf(d.out, "unknown command %s, type help\n", args[0])
+	}
+	return false
+}
+
+const debuggerHelp = `s, step [n]          execute n instructions (default 1)
+n, next              step over CALL and RST
+c, continue          run until a breakpoint or watchpoint
+bs, back [n]         step back n instructions (default 1)
+rc, rcontinue        run backwards until a breakpoint or watchpoint
+b, break [addr]      set breakpoint or list breakpoints
+d, delete [addr]     delete breakpoint or all breakpoints
+wa, watch [rwx] addr[-end]
+                     stop after an instruction reads, writes (default) or
+                     executes the range, or list watchpoints
+uw, unwatch [rwx] addr[-end]
+                     delete watchpoints of the range (of any access without
+                     rwx), or all watchpoints without a range
+r, regs              show registers and flags
+set <reg> <value>    set A B C D E H L BC DE HL SP PC or flag S Z AC P CY
+m, mem <addr> [len]  dump memory
+w, write <addr> <b>  write bytes to memory
+l, list [addr] [n]   disassemble around PC or from address
+q, quit              quit
+numbers are hex, e.g. 1A3F, 0x1A3F or 1A3FH
+`
+
+// report position after reverse execution
+func (d *Debugger) reverseStopped(steps int) {
+	if d.processor.undo == nil {
+		fmt.Fprintln(d.out, "reverse execution is off (see Processor.EnableUndo)")
+		return
+	}
+	if d.processor.UndoDepth() == 0 {
+		fmt.Fprintln(d.out, "reached the start of the undo log")
+	}
+	fmt.Fprintf(d.out, "%d instructions undone\n", steps)
+	d.printRegisters()
+	d.printInstruction(d.processor.PC)
+}
+
+// CALL, conditional call and RST
+func isCall(opcode byte) bool {
+	return opcode == 0xCD || opcode&0xC7 == 0xC4 || opcode&0xC7 == 0xC7 ||
+		opcode == 0xDD || opcode == 0xED || opcode == 0xFD
+}
+
+// find an address before pc which decodes exactly into pc
+func (d *Debugger) findStart(pc uint16) uint16 {
+	for back := uint16(8); back > 0; back-- {
+		if back > pc {
+			continue
+		}
+		address := pc - back
+		for address != pc && pc-address <= back {
+			address += uint16(disasm.Disassemble(d.processor.memory, address).Length)
+		}
+		if address == pc {
+			return pc - back
+		}
+	}
+	return pc
+}
+
+// print one instruction and return its length
+func (d *Debugger) printInstruction(address uint16) int {
+	inst := disasm.Disassemble(d.processor.memory, address)
+	marker := "  "
+	if address == d.processor.PC {
+		marker = "=>"
+	}
+	if d.breakpoints[address] {
+		marker = "* "
+		if address == d.processor.PC {
+			marker = "*>"
+		}
+	}
+	fmt.Fprintf(d.out, "%s %s\n", marker, inst.Listing())
+	return inst.Length
+}
+
+func (d *Debugger) printRegisters() {
+	p := d.processor
+	flag := func(set bool, name string) string {
+		if set {
+			return name
+		}
+		return strings.Repeat("-", len(name))
+	}
+	fmt.Fprintf(d.out, "A=%02X BC=%02X%02X DE=%02X%02X HL=%02X%02X SP=%04X PC=%04X  %s %s %s %s %s  INTE=%t HALT=%t CYCLES=%d\n",
+		p.A, p.B, p.C, p.D, p.E, p.H, p.L, p.SP, p.PC,
+		flag(p.Sign, "S"), flag(p.Zero, "Z"), flag(p.AuxiliaryCarry, "AC"),
+		flag(p.Parity, "P"), flag(p.Carry, "CY"),
+		p.IsInteruptsEnabled, p.IsHalt, p.Cycles)
+}
+
+func (d *Debugger) listBreakpoints() {
+	var addresses []int
+	for address := range d.breakpoints {
+		addresses = append(addresses, int(address))
+	}
+	sort.Ints(addresses)
+	for _, address := range addresses {
+		fmt.Fprintf(d.out, "%04X\n", address)
+	}
+}
+
+func (d *Debugger) listWatchpoints() {
+	for _, w := range d.processor.Watchpoints() {
+		if w.Start == w.End {
+			fmt.Fprintf(d.out, "%-3s %04X\n", w.Kind, w.Start)
+		} else {
+			fmt.Fprintf(d.out, "%-3s %04X-%04X\n", w.Kind, w.Start, w.End)
+		}
+	}
+}
+
+// report watchpoint hit and stop before the next instruction
+func (d *Debugger) onWatch(p *Processor, hit WatchHit) {
+	if hit.Kind == WatchWrite {
+		fmt.Fprintf(d.out, "watchpoint %s %04X at %04X: %02X -> %02X\n", hit.Kind, hit.Address, hit.PC, hit.Old, hit.New)
+	} else {
+		fmt.Fprintf(d.out, "watchpoint %s %04X at %04X: %02X\n", hit.Kind, hit.Address, hit.PC, hit.Old)
+	}
+	d.watchHit = true
+}
+
+func (d *Debugger) setRegister(name string, value uint16) {
+	p := d.processor
+	bytes := map[string]*byte{"A": &p.A, "B": &p.B, "C": &p.C, "D": &p.D, "E": &p.E, "H": &p.H, "L": &p.L}
+	pairs := map[string][2]*byte{"BC": {&p.B, &p.C}, "DE": {&p.D, &p.E}, "HL": {&p.H, &p.L}}
+	flags := map[string]*bool{"S": &p.Sign, "Z": &p.Zero, "AC": &p.AuxiliaryCarry, "P": &p.Parity, "CY": &p.Carry}
+
+	name = strings.ToUpper(name)
+	switch {
+	case bytes[name] != nil:
+		*bytes[name] = byte(value)
+	case pairs[name][0] != nil:
+		*pairs[name][0] = byte(value >> 8)
+		*pairs[name][1] = byte(value)
+	case flags[name] != nil:
+		*flags[name] = value != 0
+	case name == "SP":
+		p.SP = value
+	case name == "PC":
+		p.PC = value
+	default:
+		fmt.Fprintf(d.out, "unknown register %s\n", name)
+		return
+	}
+	p.ClearUndo()
+	d.printRegisters()
+}
+
+func (d *Debugger) dumpMemory(address uint16, length int) {
+	for offset := 0; offset < length; offset += 16 {
+		line := address + uint16(offset)
+		var hex, text strings.Builder
+		for i := 0; i < 16 && offset+i < length; i++ {
+			b := d.processor.memory.Read(line + uint16(i))
+			fmt.Fprintf(&hex, "%02X ", b)
+			if b >= 0x20 && b < 0x7F {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(d.out, "%04X  %-48s %s\n", line, hex.String(), text.String())
+	}
+}
+
+// parse address or address range (2400-3FFF)
+func (d *Debugger) parseRange(text string) (uint16, uint16, bool) {
+	first, last, isRange := strings.Cut(text, "-")
+	start, ok := d.parseValue(first)
+	if !ok {
+		return 0, 0, false
+	}
+	if !isRange {
+		return start, start, true
+	}
+	end, ok := d.parseValue(last)
+	return start, end, ok
+}
+
+// parse hex number (1A3F, 0x1A3F, $1A3F or 1A3FH)
+func (d *Debugger) parseValue(text string) (uint16, bool) {
+	s := strings.ToUpper(text)
+	s = strings.TrimPrefix(s, "0X")
+	s = strings.TrimPrefix(s, "$")
+	s = strings.TrimSuffix(s, "H")
+	value, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		fmt.Fprintf(d.out, "invalid number %s\n", text)
+		return 0, false
+	}
+	return uint16(value), true
+}
@@ -0,0 +1,375 @@
+// Package disasm disassembles Intel 8080 machine code
+// without the need of a running processor.
+package disasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reader - memory readable by address (MMU or any memory bus)
+type Reader interface {
+	Read(address uint16) byte
+}
+
+// Bytes - byte slice as memory starting at address 0,
+// addresses outside the slice read as 0
+type Bytes []byte
+
+func (b Bytes) Read(address uint16) byte {
+	if int(address) >= len(b) {
+		return 0
+	}
+	return b[address]
+}
+
+// Instruction - one decoded instruction
+type Instruction struct {
+	Address  uint16
+	Bytes    []byte
+	Mnemonic string
+	Operands string
+	Length   int
+
+	// clock cycles (T-states), for conditional call and return
+	// Cycles is not taken and CyclesTaken is taken
+	Cycles      int
+	CyclesTaken int
+}
+
+// opcode description
+type opcode struct {
+	mnemonic string
+	operands string // fixed operands, immediate data is appended
+	length   int
+	cycles   int
+	taken    int
+}
+
+var opcodes = [0x100]opcode{
+	{"NOP", "", 1, 4, 4},       // 00
+	{"LXI", "B,", 3, 10, 10},   // 01
+	{"STAX", "B", 1, 7, 7},     // 02
+	{"INX", "B", 1, 5, 5},      // 03
+	{"INR", "B", 1, 5, 5},      // 04
+	{"DCR", "B", 1, 5, 5},      // 05
+	{"MVI", "B,", 2, 7, 7},     // 06
+	{"RLC", "", 1, 4, 4},       // 07
+	{"NOP", "", 1, 4, 4},       // 08
+	{"DAD", "B", 1, 10, 10},    // 09
+	{"LDAX", "B", 1, 7, 7},     // 0A
+	{"DCX", "B", 1, 5, 5},      // 0B
+	{"INR", "C", 1, 5, 5},      // 0C
+	{"DCR", "C", 1, 5, 5},      // 0D
+	{"MVI", "C,", 2, 7, 7},     // 0E
+	{"RRC", "", 1, 4, 4},       // 0F
+	{"NOP", "", 1, 4, 4},       // 10
+	{"LXI", "D,", 3, 10, 10},   // 11
+	{"STAX", "D", 1, 7, 7},     // 12
+	{"INX", "D", 1, 5, 5},      // 13
+	{"INR", "D", 1, 5, 5},      // 14
+	{"DCR", "D", 1, 5, 5},      // 15
+	{"MVI", "D,", 2, 7, 7},     // 16
+	{"RAL", "", 1, 4, 4},       // 17
+	{"NOP", "", 1, 4, 4},       // 18
+	{"DAD", "D", 1, 10, 10},    // 19
+	{"LDAX", "D", 1, 7, 7},     // 1A
+	{"DCX", "D", 1, 5, 5},      // 1B
+	{"INR", "E", 1, 5, 5},      // 1C
+	{"DCR", "E", 1, 5, 5},      // 1D
+	{"MVI", "E,", 2, 7, 7},     // 1E
+	{"RAR", "", 1, 4, 4},       // 1F
+	{"NOP", "", 1, 4, 4},       // 20
+	{"LXI", "H,", 3, 10, 10},   // 21
+	{"SHLD", "", 3, 16, 16},    // 22
+	{"INX", "H", 1, 5, 5},      // 23
+	{"INR", "H", 1, 5, 5},      // 24
+	{"DCR", "H", 1, 5, 5},      // 25
+	{"MVI", "H,", 2, 7, 7},     // 26
+	{"DAA", "", 1, 4, 4},       // 27
+	{"NOP", "", 1, 4, 4},       // 28
+	{"DAD", "H", 1, 10, 10},    // 29
+	{"LHLD", "", 3, 16, 16},    // 2A
+	{"DCX", "H", 1, 5, 5},      // 2B
+	{"INR", "L", 1, 5, 5},      // 2C
+	{"DCR", "L", 1, 5, 5},      // 2D
+	{"MVI", "L,", 2, 7, 7},     // 2E
+	{"CMA", "", 1, 4, 4},       // 2F
+	{"NOP", "", 1, 4, 4},       // 30
+	{"LXI", "SP,", 3, 10, 10},  // 31
+	{"STA", "", 3, 13, 13},     // 32
+	{"INX", "SP", 1, 5, 5},     // 33
+	{"INR", "M", 1, 10, 10},    // 34
+	{"DCR", "M", 1, 10, 10},    // 35
+	{"MVI", "M,", 2, 10, 10},   // 36
+	{"STC", "", 1, 4, 4},       // 37
+	{"NOP", "", 1, 4, 4},       // 38
+	{"DAD", "SP", 1, 10, 10},   // 39
+	{"LDA", "", 3, 13, 13},     // 3A
+	{"DCX", "SP", 1, 5, 5},     // 3B
+	{"INR", "A", 1, 5, 5},      // 3C
+	{"DCR", "A", 1, 5, 5},      // 3D
+	{"MVI", "A,", 2, 7, 7},     // 3E
+	{"CMC", "", 1, 4, 4},       // 3F
+	{"MOV", "B,B", 1, 5, 5},    // 40
+	{"MOV", "B,C", 1, 5, 5},    // 41
+	{"MOV", "B,D", 1, 5, 5},    // 42
+	{"MOV", "B,E", 1, 5, 5},    // 43
+	{"MOV", "B,H", 1, 5, 5},    // 44
+	{"MOV", "B,L", 1, 5, 5},    // 45
+	{"MOV", "B,M", 1, 7, 7},    // 46
+	{"MOV", "B,A", 1, 5, 5},    // 47
+	{"MOV", "C,B", 1, 5, 5},    // 48
+	{"MOV", "C,C", 1, 5, 5},    // 49
+	{"MOV", "C,D", 1, 5, 5},    // 4A
+	{"MOV", "C,E", 1, 5, 5},    // 4B
+	{"MOV", "C,H", 1, 5, 5},    // 4C
+	{"MOV", "C,L", 1, 5, 5},    // 4D
+	{"MOV", "C,M", 1, 7, 7},    // 4E
+	{"MOV", "C,A", 1, 5, 5},    // 4F
+	{"MOV", "D,B", 1, 5, 5},    // 50
+	{"MOV", "D,C", 1, 5, 5},    // 51
+	{"MOV", "D,D", 1, 5, 5},    // 52
+	{"MOV", "D,E", 1, 5, 5},    // 53
+	{"MOV", "D,H", 1, 5, 5},    // 54
+	{"MOV", "D,L", 1, 5, 5},    // 55
+	{"MOV", "D,M", 1, 7, 7},    // 56
+	{"MOV", "D,A", 1, 5, 5},    // 57
+	{"MOV", "E,B", 1, 5, 5},    // 58
+	{"MOV", "E,C", 1, 5, 5},    // 59
+	{"MOV", "E,D", 1, 5, 5},    // 5A
+	{"MOV", "E,E", 1, 5, 5},    // 5B
+	{"MOV", "E,H", 1, 5, 5},    // 5C
+	{"MOV", "E,L", 1, 5, 5},    // 5D
+	{"MOV", "E,M", 1, 7, 7},    // 5E
+	{"MOV", "E,A", 1, 5, 5},    // 5F
+	{"MOV", "H,B", 1, 5, 5},    // 60
+	{"MOV", "H,C", 1, 5, 5},    // 61
+	{"MOV", "H,D", 1, 5, 5},    // 62
+	{"MOV", "H,E", 1, 5, 5},    // 63
+	{"MOV", "H,H", 1, 5, 5},    // 64
+	{"MOV", "H,L", 1, 5, 5},    // 65
+	{"MOV", "H,M", 1, 7, 7},    // 66
+	{"MOV", "H,A", 1, 5, 5},    // 67
+	{"MOV", "L,B", 1, 5, 5},    // 68
+	{"MOV", "L,C", 1, 5, 5},    // 69
+	{"MOV", "L,D", 1, 5, 5},    // 6A
+	{"MOV", "L,E", 1, 5, 5},    // 6B
+	{"MOV", "L,H", 1, 5, 5},    // 6C
+	{"MOV", "L,L", 1, 5, 5},    // 6D
+	{"MOV", "L,M", 1, 7, 7},    // 6E
+	{"MOV", "L,A", 1, 5, 5},    // 6F
+	{"MOV", "M,B", 1, 7, 7},    // 70
+	{"MOV", "M,C", 1, 7, 7},    // 71
+	{"MOV", "M,D", 1, 7, 7},    // 72
+	{"MOV", "M,E", 1, 7, 7},    // 73
+	{"MOV", "M,H", 1, 7, 7},    // 74
+	{"MOV", "M,L", 1, 7, 7},    // 75
+	{"HLT", "", 1, 7, 7},       // 76
+	{"MOV", "M,A", 1, 7, 7},    // 77
+	{"MOV", "A,B", 1, 5, 5},    // 78
+	{"MOV", "A,C", 1, 5, 5},    // 79
+	{"MOV", "A,D", 1, 5, 5},    // 7A
+	{"MOV", "A,E", 1, 5, 5},    // 7B
+	{"MOV", "A,H", 1, 5, 5},    // 7C
+	{"MOV", "A,L", 1, 5, 5},    // 7D
+	{"MOV", "A,M", 1, 7, 7},    // 7E
+	{"MOV", "A,A", 1, 5, 5},    // 7F
+	{"ADD", "B", 1, 4, 4},      // 80
+	{"ADD", "C", 1, 4, 4},      // 81
+	{"ADD", "D", 1, 4, 4},      // 82
+	{"ADD", "E", 1, 4, 4},      // 83
+	{"ADD", "H", 1, 4, 4},      // 84
+	{"ADD", "L", 1, 4, 4},      // 85
+	{"ADD", "M", 1, 7, 7},      // 86
+	{"ADD", "A", 1, 4, 4},      // 87
+	{"ADC", "B", 1, 4, 4},      // 88
+	{"ADC", "C", 1, 4, 4},      // 89
+	{"ADC", "D", 1, 4, 4},      // 8A
+	{"ADC", "E", 1, 4, 4},      // 8B
+	{"ADC", "H", 1, 4, 4},      // 8C
+	{"ADC", "L", 1, 4, 4},      // 8D
+	{"ADC", "M", 1, 7, 7},      // 8E
+	{"ADC", "A", 1, 4, 4},      // 8F
+	{"SUB", "B", 1, 4, 4},      // 90
+	{"SUB", "C", 1, 4, 4},      // 91
+	{"SUB", "D", 1, 4, 4},      // 92
+	{"SUB", "E", 1, 4, 4},      // 93
+	{"SUB", "H", 1, 4, 4},      // 94
+	{"SUB", "L", 1, 4, 4},      // 95
+	{"SUB", "M", 1, 7, 7},      // 96
+	{"SUB", "A", 1, 4, 4},      // 97
+	{"SBB", "B", 1, 4, 4},      // 98
+	{"SBB", "C", 1, 4, 4},      // 99
+	{"SBB", "D", 1, 4, 4},      // 9A
+	{"SBB", "E", 1, 4, 4},      // 9B
+	{"SBB", "H", 1, 4, 4},      // 9C
+	{"SBB", "L", 1, 4, 4},      // 9D
+	{"SBB", "M", 1, 7, 7},      // 9E
+	{"SBB", "A", 1, 4, 4},      // 9F
+	{"ANA", "B", 1, 4, 4},      // A0
+	{"ANA", "C", 1, 4, 4},      // A1
+	{"ANA", "D", 1, 4, 4},      // A2
+	{"ANA", "E", 1, 4, 4},      // A3
+	{"ANA", "H", 1, 4, 4},      // A4
+	{"ANA", "L", 1, 4, 4},      // A5
+	{"ANA", "M", 1, 7, 7},      // A6
+	{"ANA", "A", 1, 4, 4},      // A7
+	{"XRA", "B", 1, 4, 4},      // A8
+	{"XRA", "C", 1, 4, 4},      // A9
+	{"XRA", "D", 1, 4, 4},      // AA
+	{"XRA", "E", 1, 4, 4},      // AB
+	{"XRA", "H", 1, 4, 4},      // AC
+	{"XRA", "L", 1, 4, 4},      // AD
+	{"XRA", "M", 1, 7, 7},      // AE
+	{"XRA", "A", 1, 4, 4},      // AF
+	{"ORA", "B", 1, 4, 4},      // B0
+	{"ORA", "C", 1, 4, 4},      // B1
+	{"ORA", "D", 1, 4, 4},      // B2
+	{"ORA", "E", 1, 4, 4},      // B3
+	{"ORA", "H", 1, 4, 4},      // B4
+	{"ORA", "L", 1, 4, 4},      // B5
+	{"ORA", "M", 1, 7, 7},      // B6
+	{"ORA", "A", 1, 4, 4},      // B7
+	{"CMP", "B", 1, 4, 4},      // B8
+	{"CMP", "C", 1, 4, 4},      // B9
+	{"CMP", "D", 1, 4, 4},      // BA
+	{"CMP", "E", 1, 4, 4},      // BB
+	{"CMP", "H", 1, 4, 4},      // BC
+	{"CMP", "L", 1, 4, 4},      // BD
+	{"CMP", "M", 1, 7, 7},      // BE
+	{"CMP", "A", 1, 4, 4},      // BF
+	{"RNZ", "", 1, 5, 11},      // C0
+	{"POP", "B", 1, 10, 10},    // C1
+	{"JNZ", "", 3, 10, 10},     // C2
+	{"JMP", "", 3, 10, 10},     // C3
+	{"CNZ", "", 3, 11, 17},     // C4
+	{"PUSH", "B", 1, 11, 11},   // C5
+	{"ADI", "", 2, 7, 7},       // C6
+	{"RST", "0", 1, 11, 11},    // C7
+	{"RZ", "", 1, 5, 11},       // C8
+	{"RET", "", 1, 10, 10},     // C9
+	{"JZ", "", 3, 10, 10},      // CA
+	{"JMP", "", 3, 10, 10},     // CB
+	{"CZ", "", 3, 11, 17},      // CC
+	{"CALL", "", 3, 17, 17},    // CD
+	{"ACI", "", 2, 7, 7},       // CE
+	{"RST", "1", 1, 11, 11},    // CF
+	{"RNC", "", 1, 5, 11},      // D0
+	{"POP", "D", 1, 10, 10},    // D1
+	{"JNC", "", 3, 10, 10},     // D2
+	{"OUT", "", 2, 10, 10},     // D3
+	{"CNC", "", 3, 11, 17},     // D4
+	{"PUSH", "D", 1, 11, 11},   // D5
+	{"SUI", "", 2, 7, 7},       // D6
+	{"RST", "2", 1, 11, 11},    // D7
+	{"RC", "", 1, 5, 11},       // D8
+	{"RET", "", 1, 10, 10},     // D9
+	{"JC", "", 3, 10, 10},      // DA
+	{"IN", "", 2, 10, 10},      // DB
+	{"CC", "", 3, 11, 17},      // DC
+	{"CALL", "", 3, 17, 17},    // DD
+	{"SBI", "", 2, 7, 7},       // DE
+	{"RST", "3", 1, 11, 11},    // DF
+	{"RPO", "", 1, 5, 11},      // E0
+	{"POP", "H", 1, 10, 10},    // E1
+	{"JPO", "", 3, 10, 10},     // E2
+	{"XTHL", "", 1, 18, 18},    // E3
+	{"CPO", "", 3, 11, 17},     // E4
+	{"PUSH", "H", 1, 11, 11},   // E5
+	{"ANI", "", 2, 7, 7},       // E6
+	{"RST", "4", 1, 11, 11},    // E7
+	{"RPE", "", 1, 5, 11},      // E8
+	{"PCHL", "", 1, 5, 5},      // E9
+	{"JPE", "", 3, 10, 10},     // EA
+	{"XCHG", "", 1, 4, 4},      // EB
+	{"CPE", "", 3, 11, 17},     // EC
+	{"CALL", "", 3, 17, 17},    // ED
+	{"XRI", "", 2, 7, 7},       // EE
+	{"RST", "5", 1, 11, 11},    // EF
+	{"RP", "", 1, 5, 11},       // F0
+	{"POP", "PSW", 1, 10, 10},  // F1
+	{"JP", "", 3, 10, 10},      // F2
+	{"DI", "", 1, 4, 4},        // F3
+	{"CP", "", 3, 11, 17},      // F4
+	{"PUSH", "PSW", 1, 11, 11}, // F5
+	{"ORI", "", 2, 7, 7},       // F6
+	{"RST", "6", 1, 11, 11},    // F7
+	{"RM", "", 1, 5, 11},       // F8
+	{"SPHL", "", 1, 5, 5},      // F9
+	{"JM", "", 3, 10, 10},      // FA
+	{"EI", "", 1, 4, 4},        // FB
+	{"CM", "", 3, 11, 17},      // FC
+	{"CALL", "", 3, 17, 17},    // FD
+	{"CPI", "", 2, 7, 7},       // FE
+	{"RST", "7", 1, 11, 11},    // FF
+}
+
+// Disassemble - decode the instruction at address
+func Disassemble(mem Reader, address uint16) Instruction {
+	code := mem.Read(address)
+	op := opcodes[code]
+
+	inst := Instruction{
+		Address:     address,
+		Mnemonic:    op.mnemonic,
+		Operands:    op.operands,
+		Length:      op.length,
+		Cycles:      op.cycles,
+		CyclesTaken: op.taken,
+	}
+	for i := 0; i < op.length; i++ {
+		inst.Bytes = append(inst.Bytes, mem.Read(address+uint16(i)))
+	}
+
+	switch op.length {
+	case 2:
+		inst.Operands += Hex(uint16(inst.Bytes[1]), 2)
+	case 3:
+		inst.Operands += Hex(uint16(inst.Bytes[2])<<8|uint16(inst.Bytes[1]), 4)
+	}
+	return inst
+}
+
+// DisassembleRange - decode count instructions starting from address
+func DisassembleRange(mem Reader, address uint16, count int) []Instruction {
+	var insts []Instruction
+	for i := 0; i < count; i++ {
+		inst := Disassemble(mem, address)
+		insts = append(insts, inst)
+		address += uint16(inst.Length)
+	}
+	return insts
+}
+
+// Hex - format value in Intel notation (e.g. 0FFH) with the given digits
+func Hex(value uint16, digits int) string {
+	s := fmt.Sprintf("%0*XH", digits, value)
+	if s[0] >= 'A' && s[0] <= 'F' {
+		s = "0" + s
+	}
+	return s
+}
+
+// String - assembly text, the mnemonic is padded to 4 columns, e.g. "MVI  B,12H"
+func (i Instruction) String() string {
+	if i.Operands == "" {
+		return i.Mnemonic
+	}
+	return fmt.Sprintf("%-4s %s", i.Mnemonic, i.Operands)
+}
+
+// HexBytes - instruction bytes in hex, e.g. "3E 12"
+func (i Instruction) HexBytes() string {
+	var parts []string
+	for _, b := range i.Bytes {
+		parts = append(parts, fmt.Sprintf("%02X", b))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Listing - listing line with address, bytes and assembly text,
+// e.g. "0100  06 12     MVI  B,12H"
+func (i Instruction) Listing() string {
+	return fmt.Sprintf("%04X  %-8s  %s", i.Address, i.HexBytes(), i.String())
+}
@@ -0,0 +1,92 @@
+package disasm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		code        Bytes
+		text        string
+		length      int
+		cycles      int
+		cyclesTaken int
+	}{
+		{Bytes{0x00}, "NOP", 1, 4, 4},
+		{Bytes{0x06, 0x12}, "MVI  B,12H", 2, 7, 7},
+		{Bytes{0x3E, 0xFF}, "MVI  A,0FFH", 2, 7, 7},
+		{Bytes{0x3E, 0x0A}, "MVI  A,0AH", 2, 7, 7},
+		{Bytes{0x36, 0x05}, "MVI  M,05H", 2, 10, 10},
+		{Bytes{0xE6, 0x0F}, "ANI  0FH", 2, 7, 7},
+		{Bytes{0xD3, 0xFE}, "OUT  0FEH", 2, 10, 10},
+		{Bytes{0x01, 0x34, 0x12}, "LXI  B,1234H", 3, 10, 10},
+		{Bytes{0x21, 0x00, 0xF0}, "LXI  H,0F000H", 3, 10, 10},
+		{Bytes{0x32, 0x00, 0x20}, "STA  2000H", 3, 13, 13},
+		{Bytes{0xC3, 0x00, 0x01}, "JMP  0100H", 3, 10, 10},
+		{Bytes{0xCD, 0xAB, 0xCD}, "CALL 0CDABH", 3, 17, 17},
+		{Bytes{0xC4, 0x00, 0x00}, "CNZ  0000H", 3, 11, 17},
+		{Bytes{0xC0}, "RNZ", 1, 5, 11},
+		{Bytes{0x7E}, "MOV  A,M", 1, 7, 7},
+		{Bytes{0xF5}, "PUSH PSW", 1, 11, 11},
+		{Bytes{0xFF}, "RST  7", 1, 11, 11},
+		{Bytes{0x76}, "HLT", 1, 7, 7},
+		// undocumented opcodes decode as their documented twins
+		{Bytes{0x08}, "NOP", 1, 4, 4},
+		{Bytes{0xCB, 0x00, 0x01}, "JMP  0100H", 3, 10, 10},
+		{Bytes{0xD9}, "RET", 1, 10, 10},
+		{Bytes{0xFD, 0x00, 0x01}, "CALL 0100H", 3, 17, 17},
+		// operands past the end of memory read as 0
+		{Bytes{0xC3}, "JMP  0000H", 3, 10, 10},
+	}
+	for _, tt := range tests {
+		inst := Disassemble(tt.code, 0)
+		if inst.String() != tt.text || inst.Length != tt.length || len(inst.Bytes) != tt.length {
+			t.Errorf("% X: %q length %d, want %q length %d", []byte(tt.code), inst.String(), inst.Length, tt.text, tt.length)
+		}
+		if inst.Cycles != tt.cycles || inst.CyclesTaken != tt.cyclesTaken {
+			t.Errorf("% X: cycles %d/%d, want %d/%d", []byte(tt.code), inst.Cycles, inst.CyclesTaken, tt.cycles, tt.cyclesTaken)
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	tests := []struct {
+		value  uint16
+		digits int
+		want   string
+	}{
+		{0x12, 2, "12H"},
+		{0xFF, 2, "0FFH"},
+		{0x0F, 2, "0FH"},
+		{0x00, 2, "00H"},
+		{0x0000, 4, "0000H"},
+		{0x9FFF, 4, "9FFFH"},
+		{0xA000, 4, "0A000H"},
+		{0x0ABC, 4, "0ABCH"},
+	}
+	for _, tt := range tests {
+		if got := Hex(tt.value, tt.digits); got != tt.want {
+			t.Errorf("Hex(%X, %d) = %q, want %q", tt.value, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestDisassembleRange(t *testing.T) {
+	code := Bytes{0x00, 0x06, 0x12, 0xC3, 0x00, 0x01, 0x76}
+	want := []string{
+		"0000  00        NOP",
+		"0001  06 12     MVI  B,12H",
+		"0003  C3 00 01  JMP  0100H",
+		"0006  76        HLT",
+	}
+	insts := DisassembleRange(code, 0, len(want))
+	for i, inst := range insts {
+		if inst.Listing() != want[i] {
+			t.Errorf("%d: %q, want %q", i, inst.Listing(), want[i])
+		}
+	}
+	if !bytes.Equal(insts[2].Bytes, []byte{0xC3, 0x00, 0x01}) || insts[2].HexBytes() != "C3 00 01" {
+		t.Errorf("bytes % X %q", insts[2].Bytes, insts[2].HexBytes())
+	}
+}
@@ -0,0 +1,179 @@
+package gomu8080
+
+import (
+	"errors"
+	"os"
+)
+
+// IBM 3740 8" single sided single density geometry
+const (
+	DiskTracks     = 77
+	DiskSectors    = 26 // sectors are numbered from 1
+	DiskSectorSize = 128
+	DiskImageSize  = DiskTracks * DiskSectors * DiskSectorSize
+)
+
+// disk controller I/O ports
+const (
+	DiskDrivePort   = 0x10 // out - select drive (0 = A)
+	DiskTrackPort   = 0x11 // out - track
+	DiskSectorPort  = 0x12 // out - sector (1-26)
+	DiskCommandPort = 0x13 // out - 0 read or 1 write sector with DMA
+	DiskStatusPort  = 0x14 // in - 0 ok, 1 error of the last command or drive select
+	DiskDMALowPort  = 0x15 // out - DMA address low byte
+	DiskDMAHighPort = 0x16 // out - DMA address high byte
+)
+
+const (
+	diskCommandRead  = 0
+	diskCommandWrite = 1
+	diskStatusOK     = 0
+	diskStatusError  = 1
+)
+
+// DiskImage - IBM 3740 disk image, sectors are stored in physical order
+// track 0 sector 1 first, written sectors go straight to the file
+type DiskImage struct {
+	file     *os.File
+	ReadOnly bool
+}
+
+// OpenDiskImage - open image file, read only when the file can not be written
+func OpenDiskImage(path string) (*DiskImage, error) {
+	d := &DiskImage{}
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if file, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		d.ReadOnly = true
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() > DiskImageSize {
+		file.Close()
+		return nil, errors.New("Disk: Open: Error: image is larger than IBM 3740 disk")
+	}
+	d.file = file
+	return d, nil
+}
+
+func (d *DiskImage) Close() error {
+	return d.file.Close()
+}
+
+func sectorOffset(track int, sector int) (int64, error) {
+	if track < 0 || track >= DiskTracks || sector < 1 || sector > DiskSectors {
+		return 0, errors.New("Disk: Error: invalid track or sector")
+	}
+	return int64(track*DiskSectors+sector-1) * DiskSectorSize, nil
+}
+
+// ReadSector - read sector into data, sectors past the end of a short image read as 0xE5 (formatted)
+func (d *DiskImage) ReadSector(track int, sector int, data []byte) error {
+	offset, err := sectorOffset(track, sector)
+	if err != nil {
+		return err
+	}
+	n, _ := d.file.ReadAt(data[:DiskSectorSize], offset)
+	for i := n; i < DiskSectorSize; i++ {
+		data[i] = 0xE5
+	}
+	return nil
+}
+
+func (d *DiskImage) WriteSector(track int, sector int, data []byte) error {
+	if d.ReadOnly {
+		return errors.New("Disk: Write: Error: read only image")
+	}
+	offset, err := sectorOffset(track, sector)
+	if err != nil {
+		return err
+	}
+	_, err = d.file.WriteAt(data[:DiskSectorSize], offset)
+	return err
+}
+
+// DiskController - floppy controller transferring whole sectors with DMA
+type DiskController struct {
+	memory Memory
+	Drives [4]*DiskImage
+
+	drive  byte
+	track  byte
+	sector byte
+	dma    uint16
+	status byte
+}
+
+func NewDiskController(memory Memory) *DiskController {
+	d := &DiskController{}
+	d.memory = memory
+	return d
+}
+
+func (d *DiskController) In(port byte) byte {
+	if port == DiskStatusPort {
+		return d.status
+	}
+	return 0
+}
+
+func (d *DiskController) Out(port byte, data byte) {
+	switch port {
+	case DiskDrivePort:
+		d.drive = data
+		d.status = diskStatusOK
+		if d.image() == nil {
+			d.status = diskStatusError
+		}
+	case DiskTrackPort:
+		d.track = data
+	case DiskSectorPort:
+		d.sector = data
+	case DiskDMALowPort:
+		d.dma = d.dma&0xFF00 | uint16(data)
+	case DiskDMAHighPort:
+		d.dma = d.dma&0x00FF | uint16(data)<<8
+	case DiskCommandPort:
+		d.status = diskStatusError
+		if d.transfer(data) == nil {
+			d.status = diskStatusOK
+		}
+	}
+}
+
+// image of the selected drive, nil when there is none
+func (d *DiskController) image() *DiskImage {
+	if int(d.drive) >= len(d.Drives) {
+		return nil
+	}
+	return d.Drives[d.drive]
+}
+
+func (d *DiskController) transfer(command byte) error {
+	image := d.image()
+	if image == nil {
+		return errors.New("Disk: Error: no disk in drive")
+	}
+	data := make([]byte, DiskSectorSize)
+	switch command {
+	case diskCommandRead:
+		if err := image.ReadSector(int(d.track), int(d.sector), data); err != nil {
+			return err
+		}
+		for i, b := range data {
+			d.memory.Write(d.dma+uint16(i), b)
+		}
+		return nil
+	case diskCommandWrite:
+		for i := range data {
+			data[i] = d.memory.Read(d.dma + uint16(i))
+		}
+		return image.WriteSector(int(d.track), int(d.sector), data)
+	}
+	return errors.New("Disk: Error: unknown command")
+}
@@ -0,0 +1,139 @@
+package gomu8080
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// short image file of n sectors, every byte of sector i is i
+func diskImageFile(t *testing.T, n int) string {
+	t.Helper()
+	data := make([]byte, n*DiskSectorSize)
+	for i := range data {
+		data[i] = byte(i / DiskSectorSize)
+	}
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// disk controller with the image in drive A
+func diskController(t *testing.T, path string) (*DiskController, *MMU) {
+	t.Helper()
+	image, err := OpenDiskImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { image.Close() })
+	mmu := NewMMU()
+	d := NewDiskController(mmu)
+	d.Drives[0] = image
+	return d, mmu
+}
+
+// select drive, track, sector and DMA address, then run the command,
+// returns the status
+func diskCommand(d *DiskController, drive byte, track byte, sector byte, dma uint16, command byte) byte {
+	d.Out(DiskDrivePort, drive)
+	d.Out(DiskTrackPort, track)
+	d.Out(DiskSectorPort, sector)
+	d.Out(DiskDMALowPort, byte(dma))
+	d.Out(DiskDMAHighPort, byte(dma>>8))
+	d.Out(DiskCommandPort, command)
+	return d.In(DiskStatusPort)
+}
+
+func TestDiskControllerReadWrite(t *testing.T) {
+	path := diskImageFile(t, 100)
+	d, mmu := diskController(t, path)
+
+	// track 1 sector 5 is sector 30 of the image
+	if status := diskCommand(d, 0, 1, 5, 0x8000, diskCommandRead); status != diskStatusOK {
+		t.Fatalf("read status %d", status)
+	}
+	if want := bytes.Repeat([]byte{30}, DiskSectorSize); !bytes.Equal(mmu.Memory[0x8000:0x8080], want) {
+		t.Errorf("read % X", mmu.Memory[0x8000:0x8080])
+	}
+
+	// past the end of the short image the sector is formatted
+	if status := diskCommand(d, 0, 76, 26, 0x9000, diskCommandRead); status != diskStatusOK {
+		t.Fatalf("read status %d", status)
+	}
+	if want := bytes.Repeat([]byte{0xE5}, DiskSectorSize); !bytes.Equal(mmu.Memory[0x9000:0x9080], want) {
+		t.Errorf("read % X", mmu.Memory[0x9000:0x9080])
+	}
+
+	copy(mmu.Memory[0xA000:], bytes.Repeat([]byte("W"), DiskSectorSize))
+	if status := diskCommand(d, 0, 2, 1, 0xA000, diskCommandWrite); status != diskStatusOK {
+		t.Fatalf("write status %d", status)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := 2 * DiskSectors * DiskSectorSize
+	if !bytes.Equal(data[offset:offset+DiskSectorSize], bytes.Repeat([]byte("W"), DiskSectorSize)) {
+		t.Errorf("written sector % X", data[offset:offset+DiskSectorSize])
+	}
+	if data[offset-1] != byte(offset/DiskSectorSize-1) || data[offset+DiskSectorSize] != byte(offset/DiskSectorSize+1) {
+		t.Error("write changed the neighbouring sectors")
+	}
+}
+
+func TestDiskControllerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		drive   byte
+		track   byte
+		sector  byte
+		command byte
+	}{
+		{"track out of range", 0, DiskTracks, 1, diskCommandRead},
+		{"write track out of range", 0, 0xFF, 1, diskCommandWrite},
+		{"sector 0", 0, 0, 0, diskCommandRead},
+		{"sector out of range", 0, 0, DiskSectors + 1, diskCommandWrite},
+		{"no disk in drive", 1, 0, 1, diskCommandRead},
+		{"invalid drive", 9, 0, 1, diskCommandRead},
+		{"unknown command", 0, 0, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := diskImageFile(t, 10)
+			d, mmu := diskController(t, path)
+			for i := range mmu.Memory[0x8000:0x8080] {
+				mmu.Memory[0x8000+i] = 0x55
+			}
+			if status := diskCommand(d, tt.drive, tt.track, tt.sector, 0x8000, tt.command); status != diskStatusError {
+				t.Errorf("status %d, want %d", status, diskStatusError)
+			}
+			if mmu.Memory[0x8000] != 0x55 {
+				t.Error("failed command transferred data")
+			}
+			data, _ := os.ReadFile(path)
+			if len(data) != 10*DiskSectorSize {
+				t.Errorf("failed command changed the image to %d bytes", len(data))
+			}
+			// a good command clears the status
+			if status := diskCommand(d, 0, 0, 1, 0x8000, diskCommandRead); status != diskStatusOK {
+				t.Errorf("status %d after a good read", status)
+			}
+		})
+	}
+}
+
+func TestDiskControllerSelect(t *testing.T) {
+	d, _ := diskController(t, diskImageFile(t, 1))
+	for _, tt := range []struct {
+		drive  byte
+		status byte
+	}{{0, diskStatusOK}, {1, diskStatusError}, {4, diskStatusError}, {0, diskStatusOK}} {
+		d.Out(DiskDrivePort, tt.drive)
+		if status := d.In(DiskStatusPort); status != tt.status {
+			t.Errorf("select %d: status %d, want %d", tt.drive, status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+func main() {
+	binPath := flag.String("o", "", "output flat binary file")
+	hexPath := flag.String("hex", "", "output Intel HEX file")
+	listPath := flag.String("lst", "", "output listing file")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Println("usage: asm [-o file.bin] [-hex file.hex] [-lst file.lst] file.asm")
+		os.Exit(2)
+	}
+
+	src, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	program, err := asm.Assemble(string(src))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *binPath != "" {
+		if err := os.WriteFile(*binPath, program.Binary(), 0644); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *hexPath != "" {
+		if err := writeFile(*hexPath, program.WriteHex); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *listPath != "" {
+		if err := writeFile(*listPath, program.WriteListing); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("%d bytes, origin %04X\n", len(program.Binary()), program.Origin)
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
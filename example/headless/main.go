@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/detohm/gomu8080"
+)
+
+func main() {
+	path := flag.String("path", "", "path to the Space Invaders rom directory (ending with a separator)")
+	frames := flag.Int("frames", 0, "frames to run (default: until the end of the input script or played movie, or 600)")
+	inputPath := flag.String("input", "", "input script, inputs held per frame range")
+	outDir := flag.String("out", "frames", "output directory of the PNG frames")
+	every := flag.Int("every", 1, "write every n-th frame, 0 writes only the last frame")
+	overlay := flag.String("overlay", "none", "colour overlay of the frames")
+	configPath := flag.String("config", "", "JSON config of the dip switches")
+	recordPath := flag.String("record", "", "record the scripted inputs into a movie file")
+	playPath := flag.String("play", "", "play a movie file back instead of the input script, exits with an error on desync")
+	flag.Parse()
+
+	mmu := gomu8080.NewMMU()
+	p := gomu8080.NewProcessor(mmu, false)
+	if err := gomu8080.LoadInvadersROM(mmu, *path); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	machine := gomu8080.NewMachine(p, mmu)
+
+	preset, ok := gomu8080.OverlayPresets[*overlay]
+	if !ok {
+		fmt.Printf("unknown overlay %q\n", *overlay)
+		os.Exit(2)
+	}
+	machine.Video = gomu8080.NewVideo(gomu8080.VideoOptions{Overlay: preset})
+
+	if *configPath != "" {
+		config, err := gomu8080.LoadConfig(*configPath)
+		if err == nil {
+			err = machine.Controls.SetDipSwitches(config.DipSwitches)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	script := &gomu8080.InputScript{}
+	if *inputPath != "" {
+		var err error
+		if script, err = gomu8080.LoadInputScript(*inputPath); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *frames <= 0 {
+		*frames = script.Frames()
+		if *playPath != "" {
+			*frames = math.MaxInt32
+		}
+		if *frames == 0 {
+			*frames = 600
+		}
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// live machine, or movie recording or playback
+	var runner gomu8080.FrameRunner
+	var movie io.Closer
+	switch {
+	case *playPath != "":
+		player, err := gomu8080.OpenMovie(*playPath, machine)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		runner, movie = player, player
+	case *recordPath != "":
+		recorder, err := gomu8080.CreateMovie(*recordPath, machine)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		runner, movie = recorder, recorder
+	}
+
+	err := run(machine, runner, script, *frames, *outDir, *every)
+	if movie != nil {
+		if cerr := movie.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// run frames, a played movie may end earlier
+func run(machine *gomu8080.Machine, runner gomu8080.FrameRunner, script *gomu8080.InputScript, frames int, outDir string, every int) error {
+	for frame := 0; frame < frames; frame++ {
+		script.Apply(machine.Controls, frame)
+		var err error
+		if runner == nil {
+			err = machine.RunFrame()
+		} else {
+			err = runner.RunFrame(machine)
+		}
+		if err == gomu8080.ErrMovieEnd {
+			fmt.Printf("movie finished after %d frames\n", frame)
+			if frame > 0 && every == 0 {
+				return saveFrame(machine, outDir, frame-1)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		last := frame == frames-1
+		if last || (every > 0 && frame%every == 0) {
+			if err := saveFrame(machine, outDir, frame); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func saveFrame(machine *gomu8080.Machine, outDir string, frame int) error {
+	return machine.SaveFramePNG(filepath.Join(outDir, fmt.Sprintf("frame%05d.png", frame)))
+}
@@ -3,22 +3,98 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/detohm/gomu8080"
+	"github.com/detohm/gomu8080/disasm"
+	"github.com/detohm/gomu8080/frontend"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// frames between rewind snapshots
+const rewindInterval = 5
+
 func main() {
 	path := flag.String("path", "", "")       // TODO - add more detail
 	debugMode := flag.Bool("debug", true, "") // TODO - add more detail
 	isSpaceInvader := flag.Bool("spaceinvader", false, "")
+	list := flag.Bool("list", false, "print disassembly listing of the rom file")
+	origin := flag.Uint("org", 0x0100, "load address of the rom file for listing")
+	useDebugger := flag.Bool("debugger", false, "start the interactive debugger (Ctrl-C or F12 breaks into it)")
+	undoLimit := flag.Int("undo", 0, "instructions kept to step back in the debugger with back and rcontinue (0 disables reverse execution)")
+	cpmDir := flag.String("dir", "", "host directory of CP/M drive A (default: directory of the program)")
+	disks := flag.String("disk", "", "comma separated IBM 3740 disk images for drives A-D, boots CP/M 2.2 from drive A")
+	sound := flag.String("sound", "audio", "Space Invaders sound output: audio, log (print sound events) or off")
+	samples := flag.String("samples", "", "directory of Space Invaders WAV samples 0.wav-9.wav (missing samples are synthesized)")
+	gdbAddress := flag.String("gdb", "", "serve GDB remote protocol on host:port or unix:/path/to/socket")
+	overlay := flag.String("overlay", "none", "Space Invaders colour overlay: "+strings.Join(gomu8080.OverlayNames(), ", "))
+	scanlines := flag.Bool("scanlines", false, "darken every other line like a CRT")
+	persistence := flag.Float64("persistence", 0, "phosphor persistence, fraction of brightness kept per frame (0-1)")
+	highScoreDir := flag.String("hiscores", "", "directory of Space Invaders high score files, one per ROM set (default: gomu8080 in the user config directory)")
+	recordPath := flag.String("record", "", "record Space Invaders inputs from power up into a movie file")
+	playPath := flag.String("play", "", "play a Space Invaders movie file back, desyncs are reported")
+	rewindSeconds := flag.Int("rewind", 30, "seconds of Space Invaders gameplay kept to rewind with Backspace (0 disables rewind)")
+	configPath := flag.String("config", "", "JSON config of Space Invaders dip switches, key and gamepad bindings")
 	flag.Parse()
 
 	mmu := gomu8080.NewMMU()
 	p := gomu8080.NewProcessor(mmu, *debugMode)
+	p.EnableUndo(*undoLimit)
+
+	// disassembly listing
+	if *list {
+		bytes, err := os.ReadFile(*path)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := mmu.Load(len(bytes), bytes, int(*origin)); err != nil {
+			fmt.Println(err)
+			return
+		}
+		for address := int(*origin); address < int(*origin)+len(bytes); {
+			inst := disasm.Disassemble(mmu, uint16(address))
+			fmt.Println(inst.Listing())
+			address += inst.Length
+		}
+		return
+	}
+
+	// genuine CP/M booted from disk images
+	if *disks != "" {
+		cpm := gomu8080.NewCPM(p, mmu, os.Stdin, os.Stdout)
+		for drive, image := range strings.Split(*disks, ",") {
+			if err := cpm.Insert(drive, image); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+		if err := cpm.Boot(); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		debugger, err := newDebugHook(p, *useDebugger, *gdbAddress)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		for !p.IsHalt {
+			if debugger != nil && !debugger.BeforeStep() {
+				break
+			}
+			p.Run()
+		}
+		fmt.Println()
+		return
+	}
 
 	// test/console emulator
 	if !*isSpaceInvader {
@@ -28,17 +104,30 @@ func main() {
 			return
 		}
 
+		// CP/M BDOS with files in the host directory, remaining arguments are the command tail
+		dir := *cpmDir
+		if dir == "" {
+			dir = filepath.Dir(*path)
+		}
+		bdos := gomu8080.NewBDOS(dir, os.Stdin, os.Stdout)
+		if err := bdos.Boot(p, flag.Args()); err != nil {
+			fmt.Println(err)
+			return
+		}
+		bdos.Attach(p)
+
 		mmu.Load(len(bytes), bytes, 0x0100)
 		p.PC = 0x0100
 
-		for !p.IsHalt {
+		debugger, err := newDebugHook(p, *useDebugger, *gdbAddress)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 
-			if p.DebugMode {
-				fmt.Printf("PC=%04X OP=%02X %02X %02X | ",
-					p.PC,
-					mmu.Memory[p.PC],
-					mmu.Memory[p.PC+1],
-					mmu.Memory[p.PC+2]) // TODO - bound check
+		for !p.IsHalt {
+			if debugger != nil && !debugger.BeforeStep() {
+				break
 			}
 
 			p.Run()
@@ -53,23 +142,161 @@ func main() {
 	// space invader emulator
 	if *isSpaceInvader {
 
-		files := []string{"invaders_h.rom", "invaders_g.rom", "invaders_f.rom", "invaders_e.rom"}
-		pos := 0x0000
-		for _, file := range files {
-			bytes, _ := os.ReadFile(*path + file)
-			mmu.Load(len(bytes), bytes, pos)
-			pos += 0x0800
+		if err := gomu8080.LoadInvadersROM(mmu, *path); err != nil {
+			fmt.Println(err)
+			return
 		}
-
 		p.PC = 0x0000
 
-		game := gomu8080.NewGame(p, mmu, 224, 256)
+		game := frontend.NewGame(gomu8080.NewMachine(p, mmu))
+		switch *sound {
+		case "audio":
+			player, err := frontend.NewAudioSoundPlayer(*samples)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			game.SoundBoard.Player = player
+		case "log":
+			game.SoundBoard.Player = &gomu8080.LogSoundPlayer{Out: os.Stdout}
+		}
+		if *configPath != "" {
+			config, err := gomu8080.LoadConfig(*configPath)
+			if err == nil {
+				err = game.Configure(config)
+			}
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+		game.Input.OnGamepad = func(player int, name string, connected bool) {
+			if connected {
+				fmt.Printf("gamepad %q plays player %d\n", name, player)
+			} else {
+				fmt.Printf("gamepad of player %d disconnected\n", player)
+			}
+		}
+		preset, ok := gomu8080.OverlayPresets[*overlay]
+		if !ok {
+			fmt.Printf("unknown overlay %q\n", *overlay)
+			return
+		}
+		game.Video = gomu8080.NewVideo(gomu8080.VideoOptions{
+			Overlay:     preset,
+			Scale:       2,
+			Scanlines:   *scanlines,
+			Persistence: math.Max(0, math.Min(1, *persistence)),
+		})
+		if *highScoreDir == "" {
+			if dir, err := os.UserConfigDir(); err == nil {
+				*highScoreDir = filepath.Join(dir, "gomu8080")
+			}
+		}
+		// a restored high score would change the RAM of a movie
+		highScorePath := ""
+		if *highScoreDir != "" && *recordPath == "" && *playPath == "" {
+			if err := os.MkdirAll(*highScoreDir, 0755); err != nil {
+				fmt.Println(err)
+				return
+			}
+			game.OnHighScoreError = func(err error) {
+				fmt.Println(err)
+			}
+			highScorePath = filepath.Join(*highScoreDir, "invaders-"+game.ROMHash()[:16]+".hi")
+			if err := game.EnableHighScore(highScorePath); err != nil {
+				fmt.Println(err)
+				highScorePath = ""
+			}
+		}
+		if *rewindSeconds > 0 {
+			game.Rewind = gomu8080.NewRewind(*rewindSeconds*gomu8080.FrameRate/rewindInterval, rewindInterval)
+		}
+		var movie io.Closer
+		switch {
+		case *playPath != "":
+			player, err := gomu8080.OpenMovie(*playPath, game.Machine)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			game.Movie, movie = player, player
+		case *recordPath != "":
+			recorder, err := gomu8080.CreateMovie(*recordPath, game.Machine)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			game.Movie, movie = recorder, recorder
+		}
+		game.StatePath = func(slot int) string {
+			return fmt.Sprintf("%sinvaders.state%d", *path, slot)
+		}
+		if *debugMode {
+			mmu.OnROMWrite = func(address uint16, data byte) {
+				fmt.Printf("ROM write ignored: %04X=%02X at PC=%04X\n", address, data, p.PC)
+			}
+		}
+		debugger, err := newDebugHook(p, *useDebugger, *gdbAddress)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if debugger != nil {
+			game.Debugger = debugger
+		}
 		ebiten.SetWindowSize(224*2, 256*2)
 		ebiten.SetWindowTitle("Hello, World!")
 		ebiten.SetFPSMode(ebiten.FPSModeVsyncOn)
-		if err := ebiten.RunGame(game); err != nil {
+		// emulation runs in Update, one frame per tick
+		ebiten.SetMaxTPS(gomu8080.FrameRate)
+		err = ebiten.RunGame(game)
+		if movie != nil {
+			if err := movie.Close(); err != nil {
+				fmt.Println(err)
+			}
+		}
+		if highScorePath != "" {
+			if err := game.SaveHighScore(highScorePath); err != nil {
+				fmt.Println(err)
+			}
+		}
+		if err != nil && err != gomu8080.ErrQuit {
 			log.Fatal(err)
 		}
 		return
 	}
 }
+
+// interactive debugger or GDB stub, nil when neither is requested
+func newDebugHook(p *gomu8080.Processor, useDebugger bool, gdbAddress string) (gomu8080.DebugHook, error) {
+	if gdbAddress != "" {
+		stub := gomu8080.NewGDBStub(p)
+		network, address := "tcp", gdbAddress
+		if strings.HasPrefix(gdbAddress, "unix:") {
+			network, address = "unix", strings.TrimPrefix(gdbAddress, "unix:")
+		}
+		if err := stub.Listen(network, address); err != nil {
+			return nil, err
+		}
+		fmt.Printf("waiting for GDB on %s %s\n", network, address)
+		return stub, nil
+	}
+	if useDebugger {
+		return newDebugger(p), nil
+	}
+	return nil, nil
+}
+
+// interactive debugger on the terminal, Ctrl-C breaks into it
+func newDebugger(p *gomu8080.Processor) *gomu8080.Debugger {
+	debugger := gomu8080.NewDebugger(p, os.Stdin, os.Stdout)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		for range interrupt {
+			debugger.Break()
+		}
+	}()
+	return debugger
+}
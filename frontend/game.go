@@ -0,0 +1,139 @@
+// Package frontend runs the Space Invaders machine in an ebiten window
+// with keyboard, gamepad and audio.
+package frontend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/detohm/gomu8080"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Game - ebiten front-end of the machine
+type Game struct {
+	*gomu8080.Machine
+
+	// keyboard and gamepad bindings of the controls
+	Input *InputMapping
+
+	screen *ebiten.Image
+
+	// movie recording or playback (optional), inputs of a played
+	// movie replace the keyboard and gamepads until it ends
+	Movie gomu8080.FrameRunner
+
+	// save state file of each slot (optional),
+	// F1-F4 load and Shift+F1-F4 save slot 1-4 while no movie runs
+	StatePath func(slot int) string
+}
+
+func NewGame(machine *gomu8080.Machine) *Game {
+	game := Game{}
+	game.Machine = machine
+	game.Input, _ = NewInputMapping(gomu8080.DefaultConfig())
+	return &game
+}
+
+// Update - F12 breaks into the debugger (optional), Backspace rewinds (optional)
+func (g *Game) Update() error {
+	if g.Debugger != nil && inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		g.Debugger.Break()
+	}
+	// loading or saving a state is not part of a recorded or played movie
+	if g.StatePath != nil && g.Movie == nil {
+		g.updateStateSlots()
+	}
+	if g.Rewind != nil && g.Movie == nil && ebiten.IsKeyPressed(rewindKey) {
+		return g.rewind()
+	}
+	g.Input.Poll(g.Controls)
+	if g.Movie == nil {
+		return g.RunFrame()
+	}
+	err := g.Movie.RunFrame(g.Machine)
+	var desync *gomu8080.MovieDesyncError
+	switch {
+	case errors.Is(err, gomu8080.ErrMovieEnd):
+		fmt.Println("movie finished")
+		g.Movie = nil
+		return nil
+	case errors.As(err, &desync):
+		fmt.Println(err)
+		g.Movie = nil
+		return nil
+	}
+	return err
+}
+
+// held to rewind, frames rewound per tick
+const (
+	rewindKey   = ebiten.KeyBackspace
+	rewindSpeed = 2
+)
+
+// rewind gameplay while the rewind key is held, stopping at the oldest snapshot
+func (g *Game) rewind() error {
+	frame := g.FrameCount() - rewindSpeed
+	if oldest := g.Rewind.OldestFrame(); frame < oldest {
+		frame = oldest
+	}
+	if frame < 0 || frame == g.FrameCount() {
+		return nil
+	}
+	return g.RewindTo(frame)
+}
+
+// Configure - apply dip switches and input bindings of config
+func (g *Game) Configure(config *gomu8080.Config) error {
+	input, err := NewInputMapping(config)
+	if err != nil {
+		return err
+	}
+	if err := g.Controls.SetDipSwitches(config.DipSwitches); err != nil {
+		return err
+	}
+	g.Input = input
+	return nil
+}
+
+var stateSlotKeys = []ebiten.Key{ebiten.KeyF1, ebiten.KeyF2, ebiten.KeyF3, ebiten.KeyF4}
+
+// save or load state slot on hotkey
+func (g *Game) updateStateSlots() {
+	for i, key := range stateSlotKeys {
+		if !inpututil.IsKeyJustPressed(key) {
+			continue
+		}
+		slot := i + 1
+		path := g.StatePath(slot)
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if err := g.SaveStateFile(path); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("state saved to slot %d (%s)\n", slot, path)
+		} else {
+			if err := g.LoadStateFile(path); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Printf("state loaded from slot %d (%s)\n", slot, path)
+		}
+	}
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	frame := g.Render()
+	if g.screen == nil || g.screen.Bounds() != frame.Bounds() {
+		g.screen = ebiten.NewImage(frame.Bounds().Dx(), frame.Bounds().Dy())
+	}
+	g.screen.ReplacePixels(frame.Pix)
+	screen.DrawImage(g.screen, nil)
+}
+
+func (g *Game) Layout(outsideWidth int, outsideHeight int) (screenWidth, screenHeight int) {
+	bounds := g.Video.Bounds()
+	return bounds.Dx(), bounds.Dy()
+}
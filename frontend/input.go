@@ -0,0 +1,179 @@
+package frontend
+
+import (
+	"fmt"
+
+	"github.com/detohm/gomu8080"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// InputMapping - keyboard and gamepad bindings of the inputs
+type InputMapping struct {
+	keys [gomu8080.InputCount][]ebiten.Key
+
+	// buttons of the standard layout, per player
+	buttons [2][gomu8080.InputCount][]ebiten.StandardGamepadButton
+
+	// gamepad of each player, a player keeps its gamepad until it is unplugged
+	gamepads  [2]ebiten.GamepadID
+	connected [2]bool
+	ids       []ebiten.GamepadID
+
+	// OnGamepad - called when a gamepad is assigned to (connected)
+	// or removed from player 1 or 2 (optional)
+	OnGamepad func(player int, name string, connected bool)
+}
+
+// left stick deflection moving left or right
+const stickThreshold = 0.5
+
+var gamepadButtonNames = map[string]ebiten.StandardGamepadButton{
+	"RightBottom":      ebiten.StandardGamepadButtonRightBottom,
+	"RightRight":       ebiten.StandardGamepadButtonRightRight,
+	"RightLeft":        ebiten.StandardGamepadButtonRightLeft,
+	"RightTop":         ebiten.StandardGamepadButtonRightTop,
+	"FrontTopLeft":     ebiten.StandardGamepadButtonFrontTopLeft,
+	"FrontTopRight":    ebiten.StandardGamepadButtonFrontTopRight,
+	"FrontBottomLeft":  ebiten.StandardGamepadButtonFrontBottomLeft,
+	"FrontBottomRight": ebiten.StandardGamepadButtonFrontBottomRight,
+	"CenterLeft":       ebiten.StandardGamepadButtonCenterLeft,
+	"CenterRight":      ebiten.StandardGamepadButtonCenterRight,
+	"LeftStick":        ebiten.StandardGamepadButtonLeftStick,
+	"RightStick":       ebiten.StandardGamepadButtonRightStick,
+	"LeftTop":          ebiten.StandardGamepadButtonLeftTop,
+	"LeftBottom":       ebiten.StandardGamepadButtonLeftBottom,
+	"LeftLeft":         ebiten.StandardGamepadButtonLeftLeft,
+	"LeftRight":        ebiten.StandardGamepadButtonLeftRight,
+	"CenterCenter":     ebiten.StandardGamepadButtonCenterCenter,
+}
+
+// key of an ebiten key name (e.g. Space, ArrowLeft, Digit5)
+func parseKey(name string) (ebiten.Key, error) {
+	for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
+		if key.String() == name {
+			return key, nil
+		}
+	}
+	return 0, fmt.Errorf("Input: Error: unknown key %q", name)
+}
+
+func NewInputMapping(config *gomu8080.Config) (*InputMapping, error) {
+	m := &InputMapping{}
+	for name, keys := range config.Keys {
+		input, err := gomu8080.ParseInput(name)
+		if err != nil {
+			return nil, err
+		}
+		m.keys[input] = nil
+		for _, keyName := range keys {
+			key, err := parseKey(keyName)
+			if err != nil {
+				return nil, err
+			}
+			m.keys[input] = append(m.keys[input], key)
+		}
+	}
+	for action, buttons := range config.Gamepad {
+		for player := 1; player <= 2; player++ {
+			input, err := gomu8080.PlayerInput(player, action)
+			if err != nil {
+				return nil, err
+			}
+			for _, buttonName := range buttons {
+				button, ok := gamepadButtonNames[buttonName]
+				if !ok {
+					return nil, fmt.Errorf("Input: Error: unknown gamepad button %q", buttonName)
+				}
+				m.buttons[player-1][input] = append(m.buttons[player-1][input], button)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Poll - update controls from the keyboard and gamepads
+func (m *InputMapping) Poll(c *gomu8080.Controls) {
+	m.updateGamepads()
+	for input := gomu8080.Input(0); input < gomu8080.InputCount; input++ {
+		pressed := false
+		for _, key := range m.keys[input] {
+			pressed = pressed || ebiten.IsKeyPressed(key)
+		}
+		for player, id := range m.gamepads {
+			if !m.connected[player] {
+				continue
+			}
+			for _, button := range m.buttons[player][input] {
+				pressed = pressed || ebiten.IsStandardGamepadButtonPressed(id, button)
+			}
+		}
+		c.Press(input, pressed)
+	}
+
+	// left stick moves like the d-pad
+	for player, id := range m.gamepads {
+		if !m.connected[player] {
+			continue
+		}
+		left, right := gomu8080.InputP1Left, gomu8080.InputP1Right
+		if player == 1 {
+			left, right = gomu8080.InputP2Left, gomu8080.InputP2Right
+		}
+		x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		if x < -stickThreshold {
+			c.Press(left, true)
+		}
+		if x > stickThreshold {
+			c.Press(right, true)
+		}
+	}
+}
+
+// release players of unplugged gamepads and assign connected gamepads
+// of the standard layout to free players
+func (m *InputMapping) updateGamepads() {
+	m.ids = ebiten.AppendGamepadIDs(m.ids[:0])
+	for player, id := range m.gamepads {
+		if m.connected[player] && !containsGamepad(m.ids, id) {
+			m.connected[player] = false
+			if m.OnGamepad != nil {
+				m.OnGamepad(player+1, "", false)
+			}
+		}
+	}
+	for _, id := range m.ids {
+		if m.playerOf(id) >= 0 || !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+		for player := range m.gamepads {
+			if m.connected[player] {
+				continue
+			}
+			m.gamepads[player] = id
+			m.connected[player] = true
+			if m.OnGamepad != nil {
+				m.OnGamepad(player+1, ebiten.GamepadName(id), true)
+			}
+			break
+		}
+	}
+}
+
+// player index of gamepad, -1 when it is not assigned
+func (m *InputMapping) playerOf(id ebiten.GamepadID) int {
+	for player, gamepad := range m.gamepads {
+		if m.connected[player] && gamepad == id {
+			return player
+		}
+	}
+	return -1
+}
+
+func containsGamepad(ids []ebiten.GamepadID, id ebiten.GamepadID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package frontend
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/detohm/gomu8080"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+const audioSampleRate = 44100
+
+// AudioSoundPlayer - plays sound effects through ebiten audio
+type AudioSoundPlayer struct {
+	players [gomu8080.SoundCount]*audio.Player
+}
+
+// NewAudioSoundPlayer - load WAV samples named like the MAME invaders set
+// (0.wav UFO ... 9.wav extended play, in Sound order) from dir,
+// missing samples are synthesized
+func NewAudioSoundPlayer(dir string) (*AudioSoundPlayer, error) {
+	context := audio.CurrentContext()
+	if context == nil {
+		context = audio.NewContext(audioSampleRate)
+	}
+
+	a := &AudioSoundPlayer{}
+	for sound := gomu8080.Sound(0); sound < gomu8080.SoundCount; sound++ {
+		data, err := loadSample(dir, sound, context.SampleRate())
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			data = gomu8080.SynthesizeSound(sound, context.SampleRate())
+		}
+		if sound == gomu8080.SoundUFO {
+			loop := audio.NewInfiniteLoop(bytes.NewReader(data), int64(len(data)))
+			player, err := context.NewPlayer(loop)
+			if err != nil {
+				return nil, err
+			}
+			a.players[sound] = player
+			continue
+		}
+		a.players[sound] = context.NewPlayerFromBytes(data)
+	}
+	return a, nil
+}
+
+// decoded sample of sound, nil when the file does not exist
+func loadSample(dir string, sound gomu8080.Sound, sampleRate int) ([]byte, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%d.wav", int(sound))))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stream, err := wav.DecodeWithSampleRate(sampleRate, f)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(stream)
+}
+
+func (a *AudioSoundPlayer) Play(sound gomu8080.Sound) {
+	if sound < 0 || sound >= gomu8080.SoundCount {
+		return
+	}
+	player := a.players[sound]
+	if sound == gomu8080.SoundUFO && player.IsPlaying() {
+		return
+	}
+	player.Rewind()
+	player.Play()
+}
+
+func (a *AudioSoundPlayer) Stop(sound gomu8080.Sound) {
+	if sound < 0 || sound >= gomu8080.SoundCount {
+		return
+	}
+	a.players[sound].Pause()
+}
@@ -0,0 +1,483 @@
+package gomu8080
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GDBStub - GDB remote serial protocol server
+//
+// Registers use the GDB z80 layout (connect with "set architecture z80"):
+// AF, BC, DE, HL, SP, PC as 16-bit little endian, followed by IX, IY,
+// AF', BC', DE', HL', IR which read as 0 and ignore writes.
+// BeforeStep must be called before each instruction by the machine loop.
+type GDBStub struct {
+	processor *Processor
+
+	listener net.Listener
+
+	// packets from the connected client, see readPackets
+	mu       sync.Mutex
+	conn     net.Conn
+	requests chan gdbRequest
+	// packets received while running, served at the next stop
+	pending []gdbRequest
+
+	breakpoints map[uint16]bool
+	stepping    bool
+
+	// hold the processor until the first client attaches
+	waiting bool
+}
+
+// packet or marker from the client connection conn,
+// requests of a previous connection are stale and ignored
+type gdbRequest struct {
+	conn net.Conn
+	data string
+}
+
+// markers sent through requests besides packets
+const (
+	gdbInterrupt = "\x03"
+	gdbAttach    = "attach"
+	gdbDetach    = "detach"
+)
+
+// number of registers in the z80 layout
+const gdbRegisterCount = 13
+
+func NewGDBStub(p *Processor) *GDBStub {
+	g := &GDBStub{}
+	g.processor = p
+	g.requests = make(chan gdbRequest, 16)
+	g.breakpoints = map[uint16]bool{}
+	g.waiting = true
+	return g
+}
+
+// Listen - accept GDB clients on network "tcp" (e.g. "localhost:1234") or "unix" (socket path)
+// one client is served at a time, the processor stops when it attaches
+// and does not start before the first client has attached
+func (g *GDBStub) Listen(network string, address string) error {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	g.listener = listener
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			g.mu.Lock()
+			busy := g.conn != nil
+			if !busy {
+				g.conn = conn
+			}
+			g.mu.Unlock()
+			if busy {
+				conn.Close()
+				continue
+			}
+			g.requests <- gdbRequest{conn, gdbAttach}
+			go g.readPackets(conn)
+		}
+	}()
+	return nil
+}
+
+// Close - stop listening and drop the client
+func (g *GDBStub) Close() error {
+	g.mu.Lock()
+	if g.conn != nil {
+		g.conn.Close()
+	}
+	g.mu.Unlock()
+	if g.listener == nil {
+		return nil
+	}
+	return g.listener.Close()
+}
+
+// Break - stop at the next instruction and report SIGINT to the client
+func (g *GDBStub) Break() {
+	select {
+	case g.requests <- gdbRequest{nil, gdbInterrupt}:
+	default:
+	}
+}
+
+// BeforeStep - serve the client whenever the processor stops,
+// returns false when the client kills the program
+func (g *GDBStub) BeforeStep() bool {
+	if g.waiting {
+		for g.next().data != gdbAttach {
+		}
+		g.waiting = false
+		return g.serve()
+	}
+
+	stop := false
+	reply := ""
+
+	if g.stepping {
+		g.stepping = false
+		stop = true
+		reply = "S05"
+	}
+	if g.breakpoints[g.processor.PC] {
+		stop = true
+		reply = "S05"
+	}
+
+	select {
+	case request := <-g.requests:
+		if !g.current(request) {
+			break
+		}
+		switch request.data {
+		case gdbAttach:
+			// the client asks for the stop reason itself
+			stop = true
+			reply = ""
+		case gdbInterrupt:
+			stop = true
+			reply = "S02"
+		case gdbDetach:
+			g.detach()
+		default:
+			g.pending = append(g.pending, request)
+		}
+	default:
+	}
+
+	if !stop || !g.connected() {
+		return true
+	}
+	if reply != "" {
+		g.send(reply)
+	}
+	return g.serve()
+}
+
+// handle packets until the client resumes execution
+func (g *GDBStub) serve() bool {
+	p := g.processor
+	for {
+		request := g.next()
+		switch request.data {
+		case gdbAttach, gdbInterrupt:
+			continue
+		case gdbDetach:
+			g.detach()
+			return true
+		}
+
+		command, args := request.data[0], request.data[1:]
+		switch command {
+		case '?':
+			g.send("S05")
+
+		case 'g':
+			g.send(g.readRegisters())
+
+		case 'G':
+			data, err := hex.DecodeString(args)
+			if err != nil {
+				g.send("E01")
+				continue
+			}
+			for i := 0; i+1 < len(data) && i/2 < gdbRegisterCount; i += 2 {
+				g.setRegister(i/2, uint16(data[i])|uint16(data[i+1])<<8)
+			}
+			g.send("OK")
+
+		case 'p':
+			n, err := strconv.ParseUint(args, 16, 8)
+			if err != nil || n >= gdbRegisterCount {
+				g.send("E01")
+				continue
+			}
+			value := g.register(int(n))
+			g.send(fmt.Sprintf("%02x%02x", byte(value), byte(value>>8)))
+
+		case 'P':
+			parts := strings.SplitN(args, "=", 2)
+			n, err := strconv.ParseUint(parts[0], 16, 8)
+			if err != nil || len(parts) != 2 || n >= gdbRegisterCount {
+				g.send("E01")
+				continue
+			}
+			data, err := hex.DecodeString(parts[1])
+			if err != nil || len(data) < 2 {
+				g.send("E01")
+				continue
+			}
+			g.setRegister(int(n), uint16(data[0])|uint16(data[1])<<8)
+			g.send("OK")
+
+		case 'm':
+			address, length, ok := parseAddressLength(args)
+			if !ok {
+				g.send("E01")
+				continue
+			}
+			var sb strings.Builder
+			for i := 0; i < length; i++ {
+				fmt.Fprintf(&sb, "%02x", p.memory.Read(address+uint16(i)))
+			}
+			g.send(sb.String())
+
+		case 'M':
+			parts := strings.SplitN(args, ":", 2)
+			address, length, ok := parseAddressLength(parts[0])
+			if !ok || len(parts) != 2 {
+				g.send("E01")
+				continue
+			}
+			data, err := hex.DecodeString(parts[1])
+			if err != nil || len(data) != length {
+				g.send("E01")
+				continue
+			}
+			for i, b := range data {
+				p.memory.Write(address+uint16(i), b)
+			}
+			p.ClearUndo()
+			g.send("OK")
+
+		case 'c', 's':
+			if args != "" {
+				address, err := strconv.ParseUint(args, 16, 16)
+				if err != nil {
+					g.send("E01")
+					continue
+				}
+				p.PC = uint16(address)
+				p.ClearUndo()
+			}
+			g.stepping = command == 's'
+			return true
+
+		case 'Z', 'z':
+			// software (0) and hardware (1) breakpoints
+			parts := strings.Split(args, ",")
+			if len(parts) < 2 || (parts[0] != "0" && parts[0] != "1") {
+				g.send("")
+				continue
+			}
+			address, err := strconv.ParseUint(parts[1], 16, 16)
+			if err != nil {
+				g.send("E01")
+				continue
+			}
+			if command == 'Z' {
+				g.breakpoints[uint16(address)] = true
+			} else {
+				delete(g.breakpoints, uint16(address))
+			}
+			g.send("OK")
+
+		case 'q':
+			switch {
+			case strings.HasPrefix(args, "Supported"):
+				g.send("PacketSize=4000")
+			case args == "Attached":
+				g.send("1")
+			default:
+				g.send("")
+			}
+
+		case 'H':
+			g.send("OK")
+
+		case 'D':
+			g.send("OK")
+			g.detach()
+			return true
+
+		case 'k':
+			g.detach()
+			return false
+
+		default:
+			// unsupported packet
+			g.send("")
+		}
+	}
+}
+
+func (g *GDBStub) connected() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.conn != nil
+}
+
+// drop the client, the processor runs freely afterwards
+func (g *GDBStub) detach() {
+	g.mu.Lock()
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+	g.mu.Unlock()
+	g.breakpoints = map[uint16]bool{}
+	g.stepping = false
+	g.pending = nil
+}
+
+// request of the connected client or of the front-end (Break)
+func (g *GDBStub) current(request gdbRequest) bool {
+	if request.conn == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return request.conn == g.conn
+}
+
+// wait for the next request of the current client, queued packets first
+func (g *GDBStub) next() gdbRequest {
+	if len(g.pending) > 0 {
+		request := g.pending[0]
+		g.pending = g.pending[1:]
+		return request
+	}
+	for {
+		if request := <-g.requests; g.current(request) {
+			return request
+		}
+	}
+}
+
+// read packets from the client ($data#checksum or Ctrl-C) into requests
+func (g *GDBStub) readPackets(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	defer func() {
+		g.requests <- gdbRequest{conn, gdbDetach}
+	}()
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		switch c {
+		case 0x03:
+			g.requests <- gdbRequest{conn, gdbInterrupt}
+		case '$':
+			data, err := r.ReadString('#')
+			if err != nil {
+				return
+			}
+			data = data[:len(data)-1]
+			checksum := make([]byte, 2)
+			if _, err := r.Read(checksum[:1]); err != nil {
+				return
+			}
+			if _, err := r.Read(checksum[1:]); err != nil {
+				return
+			}
+			if fmt.Sprintf("%02x", gdbChecksum(data)) != strings.ToLower(string(checksum)) {
+				conn.Write([]byte("-"))
+				continue
+			}
+			conn.Write([]byte("+"))
+			if data != "" {
+				g.requests <- gdbRequest{conn, data}
+			}
+		}
+		// '+' and '-' acknowledgements from the client are ignored
+	}
+}
+
+// send packet to the client
+func (g *GDBStub) send(data string) {
+	g.mu.Lock()
+	conn := g.conn
+	g.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	fmt.Fprintf(conn, "$%s#%02x", data, gdbChecksum(data))
+}
+
+func gdbChecksum(data string) byte {
+	sum := byte(0)
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	return sum
+}
+
+// "addr,length" in hex
+func parseAddressLength(args string) (uint16, int, bool) {
+	parts := strings.Split(args, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	address, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	length, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint16(address), int(length), true
+}
+
+func (g *GDBStub) readRegisters() string {
+	var sb strings.Builder
+	for n := 0; n < gdbRegisterCount; n++ {
+		value := g.register(n)
+		fmt.Fprintf(&sb, "%02x%02x", byte(value), byte(value>>8))
+	}
+	return sb.String()
+}
+
+// register n of the z80 layout
+func (g *GDBStub) register(n int) uint16 {
+	p := g.processor
+	switch n {
+	case 0:
+		return uint16(p.A)<<8 | uint16(p.getFlags())
+	case 1:
+		return uint16(p.B)<<8 | uint16(p.C)
+	case 2:
+		return uint16(p.D)<<8 | uint16(p.E)
+	case 3:
+		return uint16(p.H)<<8 | uint16(p.L)
+	case 4:
+		return p.SP
+	case 5:
+		return p.PC
+	}
+	return 0
+}
+
+func (g *GDBStub) setRegister(n int, value uint16) {
+	p := g.processor
+	switch n {
+	case 0:
+		p.A = byte(value >> 8)
+		p.setFlags(byte(value))
+	case 1:
+		p.B, p.C = byte(value>>8), byte(value)
+	case 2:
+		p.D, p.E = byte(value>>8), byte(value)
+	case 3:
+		p.H, p.L = byte(value>>8), byte(value)
+	case 4:
+		p.SP = value
+	case 5:
+		p.PC = value
+	}
+	p.ClearUndo()
+}
@@ -0,0 +1,64 @@
+package gomu8080
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// stub attached to the server end of a pipe, packets sent to the client are read into replies
+func attachedStub(t *testing.T) (*GDBStub, net.Conn, chan string) {
+	t.Helper()
+	g := NewGDBStub(NewProcessor(NewMMU(), false))
+	g.waiting = false
+	server, client := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+	g.conn = server
+	replies := make(chan string, 16)
+	go func() {
+		r := bufio.NewReader(client)
+		for {
+			packet, err := r.ReadString('#')
+			if err != nil {
+				return
+			}
+			r.Discard(2)
+			replies <- strings.TrimPrefix(packet[:len(packet)-1], "$")
+		}
+	}()
+	return g, server, replies
+}
+
+func TestGDBStaleDetach(t *testing.T) {
+	g, _, _ := attachedStub(t)
+	old, _ := net.Pipe()
+	g.requests <- gdbRequest{old, gdbDetach}
+	if !g.BeforeStep() || !g.connected() {
+		t.Error("detach of a previous connection dropped the client")
+	}
+}
+
+func TestGDBPacketWhileRunning(t *testing.T) {
+	g, conn, replies := attachedStub(t)
+	g.processor.A = 0x42
+	g.requests <- gdbRequest{conn, "p0"}
+	if !g.BeforeStep() {
+		t.Fatal("stopped on a packet")
+	}
+	if len(g.pending) != 1 {
+		t.Fatalf("%d pending packets, want 1", len(g.pending))
+	}
+
+	// the packet is served at the next stop
+	g.requests <- gdbRequest{conn, gdbInterrupt}
+	g.requests <- gdbRequest{conn, "c"}
+	if !g.BeforeStep() {
+		t.Fatal("killed")
+	}
+	for _, want := range []string{"S02", "0042"} {
+		if got := <-replies; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package gomu8080
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// highScoreLayout - location of the high score in a ROM set
+type highScoreLayout struct {
+	address uint16 // 4 BCD digits in RAM, low byte first
+	draw    uint16 // routine drawing the high score, starts with LXI H,address and JMP
+}
+
+// layouts of the known ROM sets, Midway Space Invaders draws its high score at 0x1950
+var highScoreLayouts = []highScoreLayout{
+	{address: 0x20F4, draw: 0x1950},
+}
+
+// layout whose draw routine is found in the loaded ROM
+func (m *Machine) findHighScoreLayout() (highScoreLayout, bool) {
+	for _, layout := range highScoreLayouts {
+		code := m.mmu.Memory[layout.draw : layout.draw+4]
+		if code[0] == 0x21 && code[1] == byte(layout.address) && code[2] == byte(layout.address>>8) && code[3] == 0xC3 {
+			return layout, true
+		}
+	}
+	return highScoreLayout{}, false
+}
+
+// EnableHighScore - restore the high score from path the first time the game draws it,
+// fails when the high score is not found in the loaded ROM
+func (m *Machine) EnableHighScore(path string) error {
+	layout, ok := m.findHighScoreLayout()
+	if !ok {
+		return errors.New("Machine: EnableHighScore: Error: unknown ROM set")
+	}
+	m.highScore = &layout
+	// the game has initialised its RAM when it draws the high score
+	m.processor.OnExecute(layout.draw, func(p *Processor) bool {
+		p.OnExecute(layout.draw, nil)
+		if err := m.LoadHighScore(path); err != nil && m.OnHighScoreError != nil {
+			m.OnHighScoreError(err)
+		}
+		return false
+	})
+	return nil
+}
+
+// ROMHash - SHA-1 of the 8K ROM, identifies the ROM set
+func (m *Machine) ROMHash() string {
+	return fmt.Sprintf("%x", m.romSHA1())
+}
+
+func (m *Machine) romSHA1() [sha1.Size]byte {
+	return sha1.Sum(m.mmu.Memory[0x0000:0x2000])
+}
+
+// HighScore - high score in RAM, 0 until EnableHighScore located it
+func (m *Machine) HighScore() int {
+	if m.highScore == nil {
+		return 0
+	}
+	address := m.highScore.address
+	bcd := uint16(m.mmu.Memory[address+1])<<8 | uint16(m.mmu.Memory[address])
+	score, err := strconv.Atoi(fmt.Sprintf("%04X", bcd))
+	if err != nil {
+		// not BCD, RAM is not initialised yet
+		return 0
+	}
+	return score
+}
+
+func (m *Machine) SetHighScore(score int) error {
+	if m.highScore == nil {
+		return errors.New("Machine: SetHighScore: Error: high score is not enabled")
+	}
+	if score < 0 || score > 9999 {
+		return errors.New("Machine: SetHighScore: Error: score must be 0 - 9999")
+	}
+	bcd, _ := strconv.ParseUint(fmt.Sprintf("%04d", score), 16, 16)
+	m.mmu.Memory[m.highScore.address] = byte(bcd)
+	m.mmu.Memory[m.highScore.address+1] = byte(bcd >> 8)
+	return nil
+}
+
+// read high score file, 0 when it does not exist
+func readHighScore(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	score, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || score < 0 || score > 9999 {
+		return 0, errors.New("Machine: HighScore: Error: invalid high score file")
+	}
+	return score, nil
+}
+
+// LoadHighScore - restore high score from file into RAM
+func (m *Machine) LoadHighScore(path string) error {
+	score, err := readHighScore(path)
+	if err != nil {
+		return err
+	}
+	if score > m.HighScore() {
+		return m.SetHighScore(score)
+	}
+	return nil
+}
+
+// SaveHighScore - write high score to file when it beats the saved one
+func (m *Machine) SaveHighScore(path string) error {
+	saved, err := readHighScore(path)
+	if err != nil {
+		return err
+	}
+	score := m.HighScore()
+	if score <= saved {
+		return nil
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(score)+"\n"), 0644)
+}
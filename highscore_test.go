@@ -0,0 +1,120 @@
+package gomu8080
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// ROM clearing the high score and drawing it twice like Space Invaders,
+// DRAW copies the high score to 2100H
+const highScoreROM = `
+	ORG	0
+	LXI	SP,2400H
+	LXI	H,9999H
+	SHLD	20F4H
+	LXI	H,0
+	SHLD	20F4H
+	CALL	1950H
+	LHLD	2100H
+	SHLD	2102H
+	CALL	1950H
+	HLT
+	ORG	1950H
+	LXI	H,20F4H
+	JMP	DRAW
+DRAW:	MOV	E,M
+	INX	H
+	MOV	D,M
+	XCHG
+	SHLD	2100H
+	RET
+`
+
+func highScoreMachine(t *testing.T, source string) *Machine {
+	t.Helper()
+	program, err := asm.Assemble(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mmu := NewMMU()
+	p := NewProcessor(mmu, false)
+	binary := program.Binary()
+	mmu.Load(len(binary), binary, 0)
+	return NewMachine(p, mmu)
+}
+
+func TestHighScoreRestoredAtDraw(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invaders.hi")
+	if err := os.WriteFile(path, []byte("1230\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := highScoreMachine(t, highScoreROM)
+	if err := m.EnableHighScore(path); err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; !m.processor.IsHalt; n++ {
+		if n > 1000 {
+			t.Fatal("program does not halt")
+		}
+		m.Process()
+	}
+	// the first draw shows the restored score, not the cleared RAM
+	if drawn := uint16(m.mmu.Memory[0x2103])<<8 | uint16(m.mmu.Memory[0x2102]); drawn != 0x1230 {
+		t.Errorf("first draw %04X, want 1230", drawn)
+	}
+	if m.HighScore() != 1230 {
+		t.Errorf("high score %d, want 1230", m.HighScore())
+	}
+
+	if err := m.SetHighScore(1500); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveHighScore(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "1500\n" {
+		t.Errorf("saved %q", data)
+	}
+}
+
+func TestHighScoreRestoreError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invaders.hi")
+	if err := os.WriteFile(path, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := highScoreMachine(t, highScoreROM)
+	var reported []error
+	m.OnHighScoreError = func(err error) {
+		reported = append(reported, err)
+	}
+	if err := m.EnableHighScore(path); err != nil {
+		t.Fatal(err)
+	}
+	for !m.processor.IsHalt {
+		m.Process()
+	}
+	if len(reported) != 1 {
+		t.Errorf("%d errors reported, want 1", len(reported))
+	}
+}
+
+func TestHighScoreUnknownROM(t *testing.T) {
+	// draw routine at the known address, but for a different field
+	m := highScoreMachine(t, `
+	ORG	1950H
+	LXI	H,20F8H
+	JMP	0
+	`)
+	if err := m.EnableHighScore(filepath.Join(t.TempDir(), "invaders.hi")); err == nil {
+		t.Error("enabled on an unknown ROM set")
+	}
+	if err := m.SetHighScore(100); err == nil {
+		t.Error("set high score on an unknown ROM set")
+	}
+	if m.HighScore() != 0 {
+		t.Errorf("high score %d on an unknown ROM set", m.HighScore())
+	}
+}
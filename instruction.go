@@ -4,12 +4,10 @@ import "fmt"
 
 // Instruction
 func (p *Processor) nop() {
-	p.dasm("NOP")
 }
 
 // Increase value of 8-bit register by 1
 func (p *Processor) inr(reg *byte) {
-	p.dasm("INR")
 	p.SetFlagsAdd(*reg, 1, 0, 3) // update only ac
 	*reg += 1
 
@@ -17,7 +15,6 @@ func (p *Processor) inr(reg *byte) {
 
 // Decrease value of 8-bit register by 1
 func (p *Processor) dcr(reg *byte) {
-	p.dasm("DCR")
 	p.SetFlagsSub(*reg, 1, 0, 3) // update ac only
 	*reg -= 1
 
@@ -25,74 +22,70 @@ func (p *Processor) dcr(reg *byte) {
 
 // Move Instruction - move data from src to dst
 func (p *Processor) mov(dst *byte, src *byte) {
-	p.dasm("MOV")
 	*dst = *src
 }
 
+// Move Instruction - move data from src to memory
+func (p *Processor) movToMemory(address uint16, src *byte) {
+	p.write(address, *src)
+}
+
 // Load Accumulator - load data from the provided address
 func (p *Processor) ldax(msb *byte, lsb *byte) {
-	p.dasm("LDAX")
 	address := uint16(*msb)<<8 | uint16(*lsb)
-	p.A = p.mmu.Memory[address]
+	p.A = p.read(address)
 }
 
 // Load accumulator direct from the operand address to A
 func (p *Processor) lda() {
-	address := uint16(p.mmu.Memory[p.PC+1]) << 8
-	address |= uint16(p.mmu.Memory[p.PC])
+	address := uint16(p.read(p.PC+1)) << 8
+	address |= uint16(p.read(p.PC))
 
-	p.dasm(fmt.Sprintf("LDA %04X", address))
-
-	p.A = p.mmu.Memory[address]
+	p.A = p.read(address)
 	p.PC += 2
 }
 
 // Store Accumulator - store data from A to the provided address
 func (p *Processor) stax(msb *byte, lsb *byte) {
-	p.dasm("STAX")
 	address := uint16(*msb)<<8 | uint16(*lsb)
-	p.mmu.Memory[address] = p.A
+	p.write(address, p.A)
 }
 
 // Store accumulator direct from A to the operand address
 func (p *Processor) sta() {
-	p.dasm("STA")
-	address := uint16(p.mmu.Memory[p.PC+1]) << 8
-	address |= uint16(p.mmu.Memory[p.PC])
-	p.mmu.Memory[address] = p.A
+	address := uint16(p.read(p.PC+1)) << 8
+	address |= uint16(p.read(p.PC))
+	p.write(address, p.A)
 	p.PC += 2
 }
 
 // Store H and L direct to the provided address and the next one
 func (p *Processor) shld() {
-	p.dasm("SHLD")
-	lsb := uint16(p.mmu.Memory[p.PC])
-	msb := uint16(p.mmu.Memory[p.PC+1])
+	lsb := uint16(p.read(p.PC))
+	msb := uint16(p.read(p.PC + 1))
 	address := (msb << 8) | lsb
 
 	if address < 65535 {
-		p.mmu.Memory[address] = p.L
-		p.mmu.Memory[address+1] = p.H
+		p.write(address, p.L)
+		p.write(address+1, p.H)
 	}
 	p.PC += 2
 }
 
 // Load H and L direct from the provided address and the next one
 func (p *Processor) lhld() {
-	p.dasm("LHLD")
-	lsb := uint16(p.mmu.Memory[p.PC])
-	msb := uint16(p.mmu.Memory[p.PC+1])
+	lsb := uint16(p.read(p.PC))
+	msb := uint16(p.read(p.PC + 1))
 	address := (msb << 8) | lsb
 	if address < 65535 {
-		p.L = p.mmu.Memory[address]
-		p.H = p.mmu.Memory[address+1]
+		p.L = p.read(address)
+		p.H = p.read(address + 1)
 	}
 	p.PC += 2
 }
 
 // Decimal Adjust Accumulator
 func (p *Processor) daa() {
-	p.dasm("DAA")
 	// divide 4-bit
 	// lsb4 := p.A & 0x0F
 
@@ -136,35 +129,30 @@ func (p *Processor) daa() {
 
 // Complement Accumulator
 func (p *Processor) cma() {
-	p.dasm("CMA")
 	p.A = 0xFF ^ p.A
 }
 
 // Complement carry
 func (p *Processor) cmc() {
-	p.dasm("CMC")
 	p.Carry = !p.Carry
 }
 
 // Set Carry
 func (p *Processor) stc() {
-	p.dasm("STC")
 	p.Carry = true
 }
 
 // Halt
 func (p *Processor) hlt() {
-	p.dasm("HLT")
 	p.IsHalt = true
 }
 
 // restart
 func (p *Processor) rst(pos uint16) {
-	p.dasm("RST")
 	msb := byte(p.PC >> 8)
 	lsb := byte(p.PC & 0x00FF)
-	p.mmu.Memory[p.SP-1] = msb
-	p.mmu.Memory[p.SP-2] = lsb
+	p.write(p.SP-1, msb)
+	p.write(p.SP-2, lsb)
 	p.SP -= 2
 
 	address := uint16(pos << 3)
@@ -205,35 +193,51 @@ func (p *Processor) getFlags() byte {
 	return flags
 }
 
+// setFlags - set all flags from flags byte
+func (p *Processor) setFlags(flags byte) {
+	p.Carry = flags&0b00000001 > 0
+	p.Parity = flags&0b00000100 > 0
+	p.AuxiliaryCarry = flags&0b00010000 > 0
+	p.Zero = flags&0b01000000 > 0
+	p.Sign = flags&0b10000000 > 0
+	p.FlagBit1 = flags&0b00000010 > 0
+	p.FlagBit3 = flags&0b00001000 > 0
+	p.FlagBit5 = flags&0b00100000 > 0
+}
+
 // in - read from specified input device to accumulator
-// TODO - implementation
 func (p *Processor) in() {
-	p.dasm("IN")
+	port := p.read(p.PC)
+	if p.io != nil {
+		p.A = p.io.In(port)
+	}
 	p.PC += 1
 }
 
 // out - send accumulator's content to the specified output device
-// TODO - implementation
 func (p *Processor) out() {
-	p.dasm("OUT")
+	port := p.read(p.PC)
+	if p.io != nil {
+		p.io.Out(port, p.A)
+	}
 	p.PC += 1
 }
 
 // Enable Interuption
 func (p *Processor) ei() {
-	p.dasm("EI")
-	// TODO - implementation
 	p.IsInteruptsEnabled = true
+	// interrupt is accepted only after the next instruction
+	p.eiDelay = true
 }
 
 // Disable Interuption
 func (p *Processor) di() {
-	p.dasm("DI")
-	// TODO - implementation
 	p.IsInteruptsEnabled = false
 }
 
 // Unimplemented
 func (p *Processor) unimplemented() {
-	p.dasm(fmt.Sprintf("%02x:UNIMPLEMENTED", p.mmu.Memory[p.PC]))
+	if p.DebugMode {
+		fmt.Printf("%02X:UNIMPLEMENTED ", p.read(p.PC-1))
+	}
 }
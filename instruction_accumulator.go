@@ -4,7 +4,6 @@ package gomu8080
 
 // Add register or memory to accumulator
 func (p *Processor) add(reg *byte) {
-	p.dasm("ADD")
 	op1 := p.A
 	op2 := *reg
 	p.SetFlagsAdd(op1, op2, 0, 1)
@@ -13,7 +12,6 @@ func (p *Processor) add(reg *byte) {
 
 // Add register or memory to accumulator with carry
 func (p *Processor) adc(reg *byte) {
-	p.dasm("ADC")
 	op1 := p.A
 	op2 := *reg
 	workValue := op2
@@ -28,7 +26,6 @@ func (p *Processor) adc(reg *byte) {
 
 // Subtract register or memory from accumulator
 func (p *Processor) sub(reg *byte) {
-	p.dasm("SUB")
 	op1 := p.A
 	op2 := *reg
 	p.SetFlagsSub(op1, op2, 0, 1)
@@ -37,7 +34,6 @@ func (p *Processor) sub(reg *byte) {
 
 // Subtract register or memory from accumulator with borrow
 func (p *Processor) sbb(reg *byte) {
-	p.dasm("SBB")
 	op1 := p.A
 	op2 := *reg
 	workValue := op2
@@ -52,7 +48,6 @@ func (p *Processor) sbb(reg *byte) {
 
 // Logical AND register or memory with accumulator
 func (p *Processor) ana(reg *byte) {
-	p.dasm("ANA")
 	p.Carry = false
 	p.AuxiliaryCarry = ((p.A | *reg) & 0x08) != 0 // TODO
 	p.A &= *reg
@@ -61,7 +56,6 @@ func (p *Processor) ana(reg *byte) {
 
 // Logical XOR register or memory with accumulator
 func (p *Processor) xra(reg *byte) {
-	p.dasm("XRA")
 	p.A ^= *reg
 	p.SetFlagsAdd(p.A, 0, 0, 0)
 
@@ -69,13 +63,11 @@ func (p *Processor) xra(reg *byte) {
 
 // Logical OR register or memory with accumulator
 func (p *Processor) ora(reg *byte) {
-	p.dasm("ORA")
 	p.A |= *reg
 	p.SetFlagsAdd(p.A, 0, 0, 0)
 }
 
 // Compare register or memory with accumulator
 func (p *Processor) cmp(reg *byte) {
-	p.dasm("CMP")
 	p.SetFlagsSub(p.A, *reg, 0, 1)
 }
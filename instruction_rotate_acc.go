@@ -4,7 +4,6 @@ package gomu8080
 
 // Rotate accumulator left
 func (p *Processor) rlc() {
-	p.dasm("RLC")
 	aux := p.A
 	p.A = aux<<1 | aux>>7
 	p.Carry = (aux >> 7) > 0
@@ -12,7 +11,6 @@ func (p *Processor) rlc() {
 
 // Rotate accumulator right
 func (p *Processor) rrc() {
-	p.dasm("RRC")
 	aux := p.A
 	p.A = aux>>1 | ((aux << 7) & 0x80)
 	p.Carry = aux&0x01 > 0
@@ -20,7 +18,6 @@ func (p *Processor) rrc() {
 
 // Rorate accumulator left through carry
 func (p *Processor) ral() {
-	p.dasm("RAL")
 	aux := p.A
 	p.A = aux << 1
 
@@ -32,7 +29,6 @@ func (p *Processor) ral() {
 
 // Rotate accumulator right through carry
 func (p *Processor) rar() {
-	p.dasm("RAR")
 	aux := p.A
 	p.A = aux >> 1
 	if p.Carry {
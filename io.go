@@ -0,0 +1,55 @@
+package gomu8080
+
+// InputDevice - device which can be read by IN instruction
+type InputDevice interface {
+	In(port byte) byte
+}
+
+// OutputDevice - device which can be written by OUT instruction
+type OutputDevice interface {
+	Out(port byte, data byte)
+}
+
+// IODevice - device which can be both read and written through ports
+type IODevice interface {
+	InputDevice
+	OutputDevice
+}
+
+// IOBus - 256 input and 256 output ports with attached devices
+type IOBus struct {
+	inputs  [0x100]InputDevice
+	outputs [0x100]OutputDevice
+}
+
+func NewIOBus() *IOBus {
+	return &IOBus{}
+}
+
+// attach device to be read from the port
+func (b *IOBus) AttachInput(port byte, device InputDevice) {
+	b.inputs[port] = device
+}
+
+// attach device to be written to the port
+func (b *IOBus) AttachOutput(port byte, device OutputDevice) {
+	b.outputs[port] = device
+}
+
+// read from the device on the port, unattached port returns 0
+func (b *IOBus) In(port byte) byte {
+	device := b.inputs[port]
+	if device == nil {
+		return 0
+	}
+	return device.In(port)
+}
+
+// write to the device on the port, unattached port ignores the data
+func (b *IOBus) Out(port byte, data byte) {
+	device := b.outputs[port]
+	if device == nil {
+		return
+	}
+	device.Out(port, data)
+}
@@ -0,0 +1,176 @@
+package gomu8080
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+)
+
+// Machine - Space Invaders hardware without a display, front-ends feed
+// Controls, run frames and show the frames rendered from video memory
+type Machine struct {
+	processor *Processor
+	mmu       *MMU
+
+	// external hardware
+	bus           *IOBus
+	ShiftRegister *ShiftRegister
+	Controls      *Controls
+	SoundBoard    *SoundBoard
+
+	// clock cycles executed in the current frame
+	frameCycles int
+	frameCount  int
+
+	// renderer of video memory, overlay and post processing
+	Video *Video
+
+	// interactive debugger (optional)
+	Debugger DebugHook
+	quit     bool
+
+	// high score located by EnableHighScore (optional)
+	highScore *highScoreLayout
+	// report failure to restore the high score (optional)
+	OnHighScoreError func(err error)
+
+	// snapshots to rewind to (optional)
+	Rewind *Rewind
+}
+
+// video timing - 2 MHz clock, 60 frames per second of 262 scanlines,
+// RST 1 at mid-screen (scanline 96) and RST 2 at the start of VBLANK (scanline 224)
+const (
+	ClockRate      = 2000000
+	FrameRate      = 60
+	CyclesPerFrame = ClockRate / FrameRate
+	scanlines      = 262
+	midScreenLine  = 96
+	vblankLine     = 224
+)
+
+// ErrQuit - returned from RunFrame when the machine is quit from the debugger
+var ErrQuit = errors.New("Machine: quit")
+
+// NewMachine - Space Invaders board around processor and mmu, the ROM is expected at 0x0000-0x1FFF
+func NewMachine(pcs *Processor, mmu *MMU) *Machine {
+	m := &Machine{}
+	m.processor = pcs
+	m.mmu = mmu
+
+	// memory map - 8K ROM, 8K RAM (1K work + 7K video) mirrored up to the top
+	mmu.MapROM(0x0000, 0x1FFF)
+	mmu.MapRAM(0x2000, 0x3FFF)
+	mmu.MapMirror(0x4000, 0xFFFF, 0x2000, 0x2000)
+
+	m.ShiftRegister = &ShiftRegister{}
+	m.Controls = NewControls()
+	m.SoundBoard = NewSoundBoard(nil)
+	m.Video = NewVideo(VideoOptions{Overlay: OverlayPresets["none"]})
+
+	// port 0-2 (in) - controls and dip switches
+	// port 2 (out) - shift amount, port 3 (in) - shifted result, port 4 (out) - shift data
+	// port 3 and 5 (out) - sound latches
+	m.bus = NewIOBus()
+	m.bus.AttachInput(0, m.Controls)
+	m.bus.AttachInput(1, m.Controls)
+	m.bus.AttachInput(2, m.Controls)
+	m.bus.AttachInput(3, m.ShiftRegister)
+	m.bus.AttachOutput(2, m.ShiftRegister)
+	m.bus.AttachOutput(4, m.ShiftRegister)
+	m.bus.AttachOutput(3, m.SoundBoard)
+	m.bus.AttachOutput(5, m.SoundBoard)
+	pcs.SetIO(m.bus)
+
+	return m
+}
+
+// ROM files of the Space Invaders set, 2K each from 0x0000
+var invadersROMFiles = []string{"invaders_h.rom", "invaders_g.rom", "invaders_f.rom", "invaders_e.rom"}
+
+// LoadInvadersROM - load the ROM files from dir (a path prefix ending with a separator) into memory
+func LoadInvadersROM(mmu *MMU, dir string) error {
+	for i, file := range invadersROMFiles {
+		bytes, err := os.ReadFile(dir + file)
+		if err != nil {
+			return err
+		}
+		if err := mmu.Load(len(bytes), bytes, i*0x0800); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunFrame - emulate one frame with the mid-screen and VBLANK interrupts
+func (m *Machine) RunFrame() error {
+	m.runUntil(CyclesPerFrame * midScreenLine / scanlines)
+	m.processor.Interrupt(0xCF) // RST 1
+	m.runUntil(CyclesPerFrame * vblankLine / scanlines)
+	m.processor.Interrupt(0xD7) // RST 2
+	m.runUntil(CyclesPerFrame)
+
+	// cycles of the last instruction beyond the frame count for the next frame
+	m.frameCycles -= CyclesPerFrame
+	m.frameCount++
+
+	if m.quit {
+		return ErrQuit
+	}
+	if m.Rewind != nil {
+		return m.Rewind.capture(m)
+	}
+	return nil
+}
+
+// execute instructions until the frame reaches cycles
+func (m *Machine) runUntil(cycles int) {
+	for m.frameCycles < cycles && !m.quit {
+		m.frameCycles += m.Process()
+	}
+}
+
+// Process - execute one instruction and return the clock cycles it consumed
+func (m *Machine) Process() int {
+	// the front-end is paused while the debugger prompts
+	if m.Debugger != nil && !m.Debugger.BeforeStep() {
+		m.quit = true
+		m.Debugger = nil
+	}
+	return m.processor.Run()
+}
+
+// FrameCount - frames emulated since power up
+func (m *Machine) FrameCount() int {
+	return m.frameCount
+}
+
+// VideoMemory - 1 bit per pixel video RAM (0x2400-0x3FFF)
+func (m *Machine) VideoMemory() []byte {
+	return m.mmu.Memory[VideoMemoryStart : VideoMemoryStart+VideoMemorySize]
+}
+
+// Render - render video memory through Video
+func (m *Machine) Render() *image.RGBA {
+	return m.Video.Render(m.VideoMemory())
+}
+
+// WriteFramePNG - render video memory as PNG
+func (m *Machine) WriteFramePNG(w io.Writer) error {
+	return png.Encode(w, m.Render())
+}
+
+// SaveFramePNG - render video memory into PNG file
+func (m *Machine) SaveFramePNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.WriteFramePNG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
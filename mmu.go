@@ -4,15 +4,137 @@ import (
 	"errors"
 )
 
+// Memory - memory bus used by the processor for every access
+type Memory interface {
+	Read(address uint16) byte
+	Write(address uint16, data byte)
+}
+
+// RegionKind - behavior of an address range in MMU
+type RegionKind int
+
+const (
+	RegionRAM      RegionKind = iota // read and write
+	RegionROM                        // read only, writes are ignored
+	RegionMirror                     // alias of another address range
+	RegionUnmapped                   // reads return open bus value, writes are ignored
+)
+
+// address range [start, end] of the memory map
+type region struct {
+	start uint16
+	end   uint16
+	kind  RegionKind
+
+	// mirror only - mirrored range [target, target+size)
+	target uint16
+	size   int
+}
+
 // MMU - Memory Management Unit
 type MMU struct {
 	Memory [65536]byte
+
+	// memory map, the latest declared region wins on overlap
+	// addresses without region are RAM
+	regions []region
+
+	// value read from unmapped address
+	OpenBus byte
+
+	// report ignored write to ROM (optional)
+	OnROMWrite func(address uint16, data byte)
 }
 
 func NewMMU() *MMU {
 	return &MMU{}
 }
 
+// read byte at address
+func (m *MMU) Read(address uint16) byte {
+	address, kind := m.resolve(address)
+	if kind == RegionUnmapped {
+		return m.OpenBus
+	}
+	return m.Memory[address]
+}
+
+// write byte at address
+func (m *MMU) Write(address uint16, data byte) {
+	address, kind := m.resolve(address)
+	switch kind {
+	case RegionROM:
+		if m.OnROMWrite != nil {
+			m.OnROMWrite(address, data)
+		}
+		return
+	case RegionUnmapped:
+		return
+	}
+	m.Memory[address] = data
+}
+
+// declare [start, end] as RAM
+func (m *MMU) MapRAM(start uint16, end uint16) error {
+	return m.mapRegion(region{start: start, end: end, kind: RegionRAM})
+}
+
+// declare [start, end] as ROM, writes are ignored and reported to OnROMWrite
+func (m *MMU) MapROM(start uint16, end uint16) error {
+	return m.mapRegion(region{start: start, end: end, kind: RegionROM})
+}
+
+// declare [start, end] as repeated mirror of [target, target+size)
+func (m *MMU) MapMirror(start uint16, end uint16, target uint16, size int) error {
+	if size <= 0 || int(target)+size > 65536 {
+		return errors.New("MMU: MapMirror: Error: invalid mirror size")
+	}
+	if start <= target+uint16(size-1) && target <= end {
+		return errors.New("MMU: MapMirror: Error: mirror overlaps its target")
+	}
+	return m.mapRegion(region{start: start, end: end, kind: RegionMirror, target: target, size: size})
+}
+
+// declare [start, end] as unmapped, reads return OpenBus
+func (m *MMU) MapUnmapped(start uint16, end uint16) error {
+	return m.mapRegion(region{start: start, end: end, kind: RegionUnmapped})
+}
+
+func (m *MMU) mapRegion(r region) error {
+	if r.end < r.start {
+		return errors.New("MMU: Map: Error: invalid address range")
+	}
+	m.regions = append(m.regions, r)
+	return nil
+}
+
+// translate address through the memory map to the backing address and its kind
+func (m *MMU) resolve(address uint16) (uint16, RegionKind) {
+	// mirrors may point to other mirrors, bound the lookups
+	for depth := 0; depth <= len(m.regions); depth++ {
+		r := m.lookup(address)
+		if r == nil {
+			return address, RegionRAM
+		}
+		if r.kind != RegionMirror {
+			return address, r.kind
+		}
+		address = r.target + uint16(int(address-r.start)%r.size)
+	}
+	return address, RegionUnmapped
+}
+
+func (m *MMU) lookup(address uint16) *region {
+	for i := len(m.regions) - 1; i >= 0; i-- {
+		r := &m.regions[i]
+		if address >= r.start && address <= r.end {
+			return r
+		}
+	}
+	return nil
+}
+
+// Load - copy data directly into memory, ignoring the memory map (e.g. for ROM images)
 func (m *MMU) Load(length int, data []byte, pos int) error {
 
 	if pos < 0 || pos >= 65536 {
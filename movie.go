@@ -0,0 +1,228 @@
+package gomu8080
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// movie format (little endian), recorded from power up
+//
+//	header - magic "G80M", version uint16, movieHeader
+//	frames - movieFrame per frame until the end of the file
+const (
+	movieMagic   = "G80M"
+	movieVersion = 1
+)
+
+type movieHeader struct {
+	ROMHash     [sha1.Size]byte
+	Ships       uint8
+	ExtraShipAt uint16
+	CoinInfo    bool
+}
+
+type movieFrame struct {
+	// inputs held during the frame, bit n is Input n
+	Inputs uint16
+	// Checksum of the machine after the frame
+	Checksum uint32
+}
+
+var (
+	ErrMovieMagic   = errors.New("Movie: Error: not a movie file")
+	ErrMovieVersion = errors.New("Movie: Error: unsupported movie version")
+	ErrMovieROM     = errors.New("Movie: Error: movie was recorded with a different ROM")
+	ErrMovieStart   = errors.New("Movie: Error: movies start at power up")
+	ErrMovieEnd     = errors.New("Movie: end of movie")
+)
+
+// MovieDesyncError - playback diverged from the recording
+type MovieDesyncError struct {
+	Frame int
+}
+
+func (e *MovieDesyncError) Error() string {
+	return fmt.Sprintf("Movie: Error: desync at frame %d", e.Frame)
+}
+
+// FrameRunner - runs one frame of the machine, live or with a movie
+type FrameRunner interface {
+	RunFrame(m *Machine) error
+}
+
+// Checksum - CRC-32 of the RAM and the processor registers
+func (m *Machine) Checksum() uint32 {
+	crc := crc32.ChecksumIEEE(m.mmu.Memory[0x2000:0x4000])
+	p := m.processor
+	registers := []byte{
+		p.A, p.B, p.C, p.D, p.E, p.H, p.L,
+		byte(p.SP), byte(p.SP >> 8), byte(p.PC), byte(p.PC >> 8),
+	}
+	return crc32.Update(crc, crc32.IEEETable, registers)
+}
+
+// MovieRecorder - records the inputs of every frame
+type MovieRecorder struct {
+	w      *bufio.Writer
+	closer io.Closer
+}
+
+// NewMovieRecorder - write movie header of the machine at power up
+func NewMovieRecorder(w io.Writer, m *Machine) (*MovieRecorder, error) {
+	if m.FrameCount() != 0 {
+		return nil, ErrMovieStart
+	}
+	r := &MovieRecorder{}
+	r.w = bufio.NewWriter(w)
+	if c, ok := w.(io.Closer); ok {
+		r.closer = c
+	}
+	dips := m.Controls.DipSwitches()
+	header := movieHeader{
+		ROMHash:     m.romSHA1(),
+		Ships:       uint8(dips.Ships),
+		ExtraShipAt: uint16(dips.ExtraShipAt),
+		CoinInfo:    dips.CoinInfo,
+	}
+	if _, err := r.w.WriteString(movieMagic); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(r.w, binary.LittleEndian, uint16(movieVersion)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(r.w, binary.LittleEndian, header); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateMovie - record movie into file
+func CreateMovie(path string, m *Machine) (*MovieRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewMovieRecorder(f, m)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// RunFrame - run a frame with the current inputs and record it
+func (r *MovieRecorder) RunFrame(m *Machine) error {
+	frame := movieFrame{Inputs: m.Controls.InputState()}
+	err := m.RunFrame()
+	frame.Checksum = m.Checksum()
+	if werr := binary.Write(r.w, binary.LittleEndian, frame); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// Close - flush the movie and close the file
+func (r *MovieRecorder) Close() error {
+	err := r.w.Flush()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// MoviePlayer - plays a movie back, replacing the inputs of every frame
+type MoviePlayer struct {
+	r      *bufio.Reader
+	closer io.Closer
+	frame  int
+}
+
+// NewMoviePlayer - read movie header, check the ROM and set the dip switches of the machine
+func NewMoviePlayer(r io.Reader, m *Machine) (*MoviePlayer, error) {
+	if m.FrameCount() != 0 {
+		return nil, ErrMovieStart
+	}
+	p := &MoviePlayer{}
+	p.r = bufio.NewReader(r)
+	if c, ok := r.(io.Closer); ok {
+		p.closer = c
+	}
+	magic := make([]byte, len(movieMagic))
+	if _, err := io.ReadFull(p.r, magic); err != nil || string(magic) != movieMagic {
+		return nil, ErrMovieMagic
+	}
+	var version uint16
+	if err := binary.Read(p.r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != movieVersion {
+		return nil, ErrMovieVersion
+	}
+	header := movieHeader{}
+	if err := binary.Read(p.r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.ROMHash != m.romSHA1() {
+		return nil, ErrMovieROM
+	}
+	dips := DipSwitches{Ships: int(header.Ships), ExtraShipAt: int(header.ExtraShipAt), CoinInfo: header.CoinInfo}
+	if err := m.Controls.SetDipSwitches(dips); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// OpenMovie - play movie file back
+func OpenMovie(path string, m *Machine) (*MoviePlayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewMoviePlayer(f, m)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// RunFrame - run the next frame of the movie, ErrMovieEnd after the last frame
+// and MovieDesyncError when the machine differs from the recording
+func (p *MoviePlayer) RunFrame(m *Machine) error {
+	frame := movieFrame{}
+	if err := binary.Read(p.r, binary.LittleEndian, &frame); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrMovieEnd
+		}
+		return err
+	}
+	m.Controls.SetInputState(frame.Inputs)
+	if err := m.RunFrame(); err != nil {
+		return err
+	}
+	p.frame++
+	if m.Checksum() != frame.Checksum {
+		return &MovieDesyncError{Frame: p.frame - 1}
+	}
+	return nil
+}
+
+// Frame - frames played back
+func (p *MoviePlayer) Frame() int {
+	return p.frame
+}
+
+func (p *MoviePlayer) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package gomu8080
 
 import (
 	"fmt"
+
+	"github.com/detohm/gomu8080/disasm"
 )
 
 type Processor struct {
@@ -35,8 +37,15 @@ type Processor struct {
 	FlagBit3 bool
 	FlagBit5 bool
 
-	// MMU
-	mmu *MMU
+	// memory bus
+	memory Memory
+
+	// devices for IN and OUT instructions
+	io IODevice
+
+	// execution hooks by address, see OnExecute and OnCall
+	executeHooks map[uint16]Hook
+	callHooks    map[uint16]Hook
 
 	// debug
 	DebugMode bool
@@ -47,19 +56,94 @@ type Processor struct {
 	// enable interupt
 	IsInteruptsEnabled bool
 
+	// interrupt is not accepted until the instruction after EI completes
+	eiDelay bool
+
+	// requested interrupt and its instruction
+	interruptPending bool
+	interruptOpcode  byte
+
 	// pre calculation for zsp flags
 	ZSP [0x100]uint8
+
+	// total clock cycles (T-states) executed
+	Cycles uint64
+
+	// clock cycles of the current instruction
+	instCycles int
+
+	// reverse execution log (optional), see EnableUndo
+	undo *undoLog
+
+	// memory watchpoints, see Watch
+	watchpoints []watchpoint
+	// address of the current instruction
+	instPC uint16
 }
 
-func NewProcessor(mmu *MMU, debugMode bool) *Processor {
+// clock cycles (T-states) of each opcode from the disassembler table,
+// conditional call and return are listed as not taken,
+// the extra cycles are added when the condition is met
+var instructionCycles = func() (cycles [0x100]int) {
+	for opcode := range cycles {
+		cycles[opcode] = disasm.Disassemble(disasm.Bytes{byte(opcode), 0, 0}, 0).Cycles
+	}
+	return cycles
+}()
+
+// extra clock cycles when a conditional call or return is taken
+const conditionalTakenCycles = 6
+
+// clock cycles spent by each Run while halted
+const haltCycles = 4
+
+func NewProcessor(memory Memory, debugMode bool) *Processor {
 	p := &Processor{}
-	p.mmu = mmu
+	p.memory = memory
 	p.DebugMode = debugMode
 	initZSPTable(p)
 	// p.FlagBit1 = true
 	return p
 }
 
+// attach devices for IN and OUT instructions
+func (p *Processor) SetIO(io IODevice) {
+	p.io = io
+}
+
+// Hook - callback on reaching a hooked address,
+// returns true when it has handled the instruction itself
+type Hook func(p *Processor) bool
+
+// OnExecute - call hook before executing the instruction at address,
+// the instruction is skipped when the hook handled it (the hook then sets PC),
+// nil removes the hook
+func (p *Processor) OnExecute(address uint16, hook Hook) {
+	if p.executeHooks == nil {
+		p.executeHooks = map[uint16]Hook{}
+	}
+	if hook == nil {
+		delete(p.executeHooks, address)
+		return
+	}
+	p.executeHooks[address] = hook
+}
+
+// OnCall - call hook when CALL (or a taken conditional call) targets address,
+// PC holds the return address while the hook runs,
+// the call is skipped as if the subroutine returned when the hook handled it,
+// nil removes the hook
+func (p *Processor) OnCall(address uint16, hook Hook) {
+	if p.callHooks == nil {
+		p.callHooks = map[uint16]Hook{}
+	}
+	if hook == nil {
+		delete(p.callHooks, address)
+		return
+	}
+	p.callHooks[address] = hook
+}
+
 func initZSPTable(p *Processor) {
 
 	for i := 0; i <= 0xFF; i++ {
@@ -121,15 +205,78 @@ func (p *Processor) GetCarry(op1 uint8, op2 uint8, carry uint8, bit uint8) bool
 	return (newCarry & uint16(0x1<<bit)) != 0
 }
 
-func (p *Processor) Run() {
+// Run - execute one instruction and return the clock cycles it consumed
+func (p *Processor) Run() int {
+	// pending interrupt is acknowledged between instructions
+	interrupt := p.interruptPending && p.IsInteruptsEnabled && !p.eiDelay
+	if p.undo != nil {
+		// idle ticks of a halted processor are not logged,
+		// stepping back from them returns to the HLT
+		if p.IsHalt && !interrupt {
+			p.undo.current = nil
+		} else {
+			p.undo.begin(p)
+		}
+	}
+	p.instPC = p.PC
+
+	if interrupt {
+		p.interruptPending = false
+		p.IsInteruptsEnabled = false
+		p.IsHalt = false
+		if p.DebugMode {
+			inst := disasm.Disassemble(disasm.Bytes{p.interruptOpcode}, 0)
+			inst.Address = p.PC
+			p.trace(inst)
+		}
+		// the instruction comes from the interrupting device, PC is untouched
+		return p.execute(p.interruptOpcode)
+	}
+	p.eiDelay = false
 
-	opcode := p.mmu.Memory[p.PC]
+	// halted processor idles until an interrupt arrives
+	if p.IsHalt {
+		p.Cycles += haltCycles
+		return haltCycles
+	}
 
-	// TODO - validate address bound
-	address := (uint16(p.H) << 8) | uint16(p.L)
+	if len(p.watchpoints) > 0 {
+		opcode := p.memory.Read(p.PC)
+		p.watch(WatchExecute, p.PC, opcode, opcode)
+	}
 
+	if len(p.executeHooks) > 0 {
+		if hook := p.executeHooks[p.PC]; hook != nil && hook(p) {
+			return 0
+		}
+	}
+
+	if p.DebugMode {
+		p.trace(disasm.Disassemble(p.memory, p.PC))
+	}
+
+	// opcode fetch is watched as execute, not as read
+	opcode := p.memory.Read(p.PC)
 	p.PC += 1
 
+	return p.execute(opcode)
+}
+
+// Interrupt - request an interrupt with the instruction to be executed on acknowledge,
+// usually RST n (0xC7 | n<<3). The request stays pending until interrupts are enabled.
+func (p *Processor) Interrupt(opcode byte) {
+	p.interruptPending = true
+	p.interruptOpcode = opcode
+}
+
+// execute opcode and return the clock cycles it consumed
+func (p *Processor) execute(opcode byte) int {
+
+	p.instCycles = instructionCycles[opcode]
+
+	// TODO - validate address bound
+	address := (uint16(p.H) << 8) | uint16(p.L)
+
 	switch opcode {
 	/* 0x */
 	case 0x00:
@@ -242,11 +389,13 @@ func (p *Processor) Run() {
 	case 0x33:
 		p.inx16(&p.SP)
 	case 0x34:
-		p.inr(&p.mmu.Memory[address])
+		p.modify(address, p.inr)
 	case 0x35:
-		p.dcr(&p.mmu.Memory[address])
+		p.modify(address, p.dcr)
 	case 0x36:
-		p.mvi(&p.mmu.Memory[address])
+		var value byte
+		p.mvi(&value)
+		p.write(address, value)
 	case 0x37:
 		p.stc()
 	case 0x38:
@@ -281,7 +430,7 @@ func (p *Processor) Run() {
 	case 0x45:
 		p.mov(&p.B, &p.L)
 	case 0x46:
-		p.mov(&p.B, &p.mmu.Memory[address])
+		p.mov(&p.B, p.operand(address))
 	case 0x47:
 		p.mov(&p.B, &p.A)
 	// C - destination
@@ -298,7 +447,7 @@ func (p *Processor) Run() {
 	case 0x4D:
 		p.mov(&p.C, &p.L)
 	case 0x4E:
-		p.mov(&p.C, &p.mmu.Memory[address])
+		p.mov(&p.C, p.operand(address))
 	case 0x4F:
 		p.mov(&p.C, &p.A)
 
@@ -317,7 +466,7 @@ func (p *Processor) Run() {
 	case 0x55:
 		p.mov(&p.D, &p.L)
 	case 0x56:
-		p.mov(&p.D, &p.mmu.Memory[address])
+		p.mov(&p.D, p.operand(address))
 	case 0x57:
 		p.mov(&p.D, &p.A)
 	// E - destination
@@ -334,7 +483,7 @@ func (p *Processor) Run() {
 	case 0x5D:
 		p.mov(&p.E, &p.L)
 	case 0x5E:
-		p.mov(&p.E, &p.mmu.Memory[address])
+		p.mov(&p.E, p.operand(address))
 	case 0x5F:
 		p.mov(&p.E, &p.A)
 
@@ -353,7 +502,7 @@ func (p *Processor) Run() {
 	case 0x65:
 		p.mov(&p.H, &p.L)
 	case 0x66:
-		p.mov(&p.H, &p.mmu.Memory[address])
+		p.mov(&p.H, p.operand(address))
 	case 0x67:
 		p.mov(&p.H, &p.A)
 	// L - destination
@@ -370,29 +519,29 @@ func (p *Processor) Run() {
 	case 0x6D:
 		p.mov(&p.L, &p.L)
 	case 0x6E:
-		p.mov(&p.L, &p.mmu.Memory[address])
+		p.mov(&p.L, p.operand(address))
 	case 0x6F:
 		p.mov(&p.L, &p.A)
 
 	/* 7x */
 	// M - destination
 	case 0x70:
-		p.mov(&p.mmu.Memory[address], &p.B)
+		p.movToMemory(address, &p.B)
 	case 0x71:
-		p.mov(&p.mmu.Memory[address], &p.C)
+		p.movToMemory(address, &p.C)
 	case 0x72:
-		p.mov(&p.mmu.Memory[address], &p.D)
+		p.movToMemory(address, &p.D)
 	case 0x73:
-		p.mov(&p.mmu.Memory[address], &p.E)
+		p.movToMemory(address, &p.E)
 	case 0x74:
-		p.mov(&p.mmu.Memory[address], &p.H)
+		p.movToMemory(address, &p.H)
 	case 0x75:
-		p.mov(&p.mmu.Memory[address], &p.L)
+		p.movToMemory(address, &p.L)
 	// HALT
 	case 0x76:
 		p.hlt()
 	case 0x77:
-		p.mov(&p.mmu.Memory[address], &p.A)
+		p.movToMemory(address, &p.A)
 	// A - destination
 	case 0x78:
 		p.mov(&p.A, &p.B)
@@ -407,7 +556,7 @@ func (p *Processor) Run() {
 	case 0x7D:
 		p.mov(&p.A, &p.L)
 	case 0x7E:
-		p.mov(&p.A, &p.mmu.Memory[address])
+		p.mov(&p.A, p.operand(address))
 	case 0x7F:
 		p.mov(&p.A, &p.A)
 
@@ -426,7 +575,7 @@ func (p *Processor) Run() {
 	case 0x85:
 		p.add(&p.L)
 	case 0x86:
-		p.add(&p.mmu.Memory[address])
+		p.add(p.operand(address))
 	case 0x87:
 		p.add(&p.A)
 	// adc
@@ -443,7 +592,7 @@ func (p *Processor) Run() {
 	case 0x8D:
 		p.adc(&p.L)
 	case 0x8E:
-		p.adc(&p.mmu.Memory[address])
+		p.adc(p.operand(address))
 	case 0x8F:
 		p.adc(&p.A)
 
@@ -462,7 +611,7 @@ func (p *Processor) Run() {
 	case 0x95:
 		p.sub(&p.L)
 	case 0x96:
-		p.sub(&p.mmu.Memory[address])
+		p.sub(p.operand(address))
 	case 0x97:
 		p.sub(&p.A)
 		// sbb
@@ -479,7 +628,7 @@ func (p *Processor) Run() {
 	case 0x9D:
 		p.sbb(&p.L)
 	case 0x9E:
-		p.sbb(&p.mmu.Memory[address])
+		p.sbb(p.operand(address))
 	case 0x9F:
 		p.sbb(&p.A)
 
@@ -498,7 +647,7 @@ func (p *Processor) Run() {
 	case 0xA5:
 		p.ana(&p.L)
 	case 0xA6:
-		p.ana(&p.mmu.Memory[address])
+		p.ana(p.operand(address))
 	case 0xA7:
 		p.ana(&p.A)
 	// xra
@@ -515,7 +664,7 @@ func (p *Processor) Run() {
 	case 0xAD:
 		p.xra(&p.L)
 	case 0xAE:
-		p.xra(&p.mmu.Memory[address])
+		p.xra(p.operand(address))
 	case 0xAF:
 		p.xra(&p.A)
 
@@ -534,7 +683,7 @@ func (p *Processor) Run() {
 	case 0xB5:
 		p.ora(&p.L)
 	case 0xB6:
-		p.ora(&p.mmu.Memory[address])
+		p.ora(p.operand(address))
 	case 0xB7:
 		p.ora(&p.A)
 	// cmp
@@ -551,7 +700,7 @@ func (p *Processor) Run() {
 	case 0xBD:
 		p.cmp(&p.L)
 	case 0xBE:
-		p.cmp(&p.mmu.Memory[address])
+		p.cmp(p.operand(address))
 	case 0xBF:
 		p.cmp(&p.A)
 
@@ -698,6 +847,45 @@ func (p *Processor) Run() {
 	if p.DebugMode {
 		p.PrintStatus()
 	}
+
+	p.Cycles += uint64(p.instCycles)
+	return p.instCycles
+}
+
+// read byte from memory bus
+func (p *Processor) read(address uint16) byte {
+	data := p.memory.Read(address)
+	if len(p.watchpoints) > 0 {
+		p.watch(WatchRead, address, data, data)
+	}
+	return data
+}
+
+// write byte to memory bus
+func (p *Processor) write(address uint16, data byte) {
+	if p.undo != nil {
+		p.undo.write(p, address)
+	}
+	if len(p.watchpoints) == 0 {
+		p.memory.Write(address, data)
+		return
+	}
+	old := p.memory.Read(address)
+	p.memory.Write(address, data)
+	p.watch(WatchWrite, address, old, data)
+}
+
+// copy of memory operand (M) for instructions which only read it
+func (p *Processor) operand(address uint16) *byte {
+	value := p.read(address)
+	return &value
+}
+
+// read-modify-write memory operand (M)
+func (p *Processor) modify(address uint16, op func(reg *byte)) {
+	value := p.read(address)
+	op(&value)
+	p.write(address, value)
 }
 
 // debug print status
@@ -747,13 +935,7 @@ func (p *Processor) SetAuxiliaryCarry(result uint16, op1 uint8, op2 uint8, isAdd
 }
 
 // DEBUGGER
-// disassemble helper
-// TODO - add operands detail
-func (p *Processor) dasm(opcode string) {
-
-	if !p.DebugMode {
-		return
-	}
-	fmt.Printf("%s ", opcode)
-
+// print disassembly of the instruction before its execution
+func (p *Processor) trace(inst disasm.Instruction) {
+	fmt.Printf("%-32s ", inst.Listing())
 }
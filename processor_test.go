@@ -0,0 +1,34 @@
+package gomu8080
+
+import (
+	"testing"
+
+	"github.com/detohm/gomu8080/disasm"
+)
+
+// executed cycles of every opcode agree with the disassembler table,
+// conditional call and return are run with all flags clear and all flags set
+// so that each condition is taken once and not taken once
+func TestInstructionCycles(t *testing.T) {
+	for opcode := 0; opcode < 0x100; opcode++ {
+		inst := disasm.Disassemble(disasm.Bytes{byte(opcode), 0, 0}, 0)
+		seen := map[int]bool{}
+		for _, flags := range []bool{false, true} {
+			mmu := NewMMU()
+			mmu.Load(3, []byte{byte(opcode), 0x00, 0x30}, 0x0100)
+			p := NewProcessor(mmu, false)
+			p.SetIO(NewIOBus())
+			p.PC = 0x0100
+			p.SP = 0x2400
+			p.Sign, p.Zero, p.Parity, p.Carry = flags, flags, flags, flags
+			cycles := p.Run()
+			if cycles != inst.Cycles && cycles != inst.CyclesTaken {
+				t.Errorf("%02X %s: %d cycles, disassembler lists %d/%d", opcode, inst.Mnemonic, cycles, inst.Cycles, inst.CyclesTaken)
+			}
+			seen[cycles] = true
+		}
+		if !seen[inst.Cycles] || !seen[inst.CyclesTaken] {
+			t.Errorf("%02X %s: executed %v, disassembler lists %d/%d", opcode, inst.Mnemonic, seen, inst.Cycles, inst.CyclesTaken)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package gomu8080
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+)
+
+var ErrRewindRange = errors.New("Rewind: Error: frame is not in the rewind buffer")
+
+// Rewind - ring buffer of compressed snapshots taken every Interval frames,
+// with the inputs of every frame to replay up to any frame in between
+type Rewind struct {
+	interval  int
+	snapshots []rewindSnapshot
+	start     int
+	count     int
+}
+
+type rewindSnapshot struct {
+	frame int
+
+	// deflated save state, including the frame count and cycles
+	state []byte
+
+	// inputs of the frames from frame on, up to the next snapshot
+	inputs []uint16
+}
+
+// NewRewind - keep capacity snapshots, one every interval frames
+func NewRewind(capacity int, interval int) *Rewind {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if interval < 1 {
+		interval = 1
+	}
+	r := &Rewind{}
+	r.interval = interval
+	r.snapshots = make([]rewindSnapshot, capacity)
+	return r
+}
+
+// snapshot i from the oldest
+func (r *Rewind) at(i int) *rewindSnapshot {
+	return &r.snapshots[(r.start+i)%len(r.snapshots)]
+}
+
+// OldestFrame - first frame that can be rewound to, -1 when the buffer is empty
+func (r *Rewind) OldestFrame() int {
+	if r.count == 0 {
+		return -1
+	}
+	return r.at(0).frame
+}
+
+// Reset - drop all snapshots
+func (r *Rewind) Reset() {
+	r.start = 0
+	r.count = 0
+}
+
+// record inputs of the frame just run and take a snapshot every interval frames
+func (r *Rewind) capture(m *Machine) error {
+	if r.count > 0 {
+		newest := r.at(r.count - 1)
+		newest.inputs = append(newest.inputs, m.Controls.InputState())
+	}
+	if m.frameCount%r.interval != 0 {
+		return nil
+	}
+	return r.snapshot(m)
+}
+
+// add snapshot of the machine, the oldest is dropped when the buffer is full
+func (r *Rewind) snapshot(m *Machine) error {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if err := m.SaveState(w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if r.count == len(r.snapshots) {
+		r.start = (r.start + 1) % len(r.snapshots)
+		r.count--
+	}
+	snapshot := r.at(r.count)
+	snapshot.frame = m.frameCount
+	snapshot.state = buf.Bytes()
+	snapshot.inputs = snapshot.inputs[:0]
+	r.count++
+	return nil
+}
+
+// RewindTo - return the machine to the start of frame, restoring the nearest
+// snapshot and replaying the recorded inputs silently, later frames are dropped
+func (m *Machine) RewindTo(frame int) error {
+	r := m.Rewind
+	if r == nil || r.count == 0 || frame < r.OldestFrame() || frame > m.frameCount {
+		return ErrRewindRange
+	}
+	i := r.count - 1
+	for r.at(i).frame > frame {
+		i--
+	}
+	snapshot := r.at(i)
+
+	// the replay is silent, the UFO loop follows the machine when it is done
+	player := m.SoundBoard.Player
+	wasLooping := m.SoundBoard.looping()
+	m.SoundBoard.Player = nil
+	defer func() {
+		m.SoundBoard.Player = player
+		m.SoundBoard.syncLoop(wasLooping)
+	}()
+	if err := m.loadState(flate.NewReader(bytes.NewReader(snapshot.state))); err != nil {
+		return err
+	}
+	inputs := append([]uint16(nil), snapshot.inputs[:frame-snapshot.frame]...)
+
+	// the replay records the kept frames again
+	r.count = i
+	if err := r.snapshot(m); err != nil {
+		return err
+	}
+
+	held := m.Controls.InputState()
+	defer m.Controls.SetInputState(held)
+	for _, input := range inputs {
+		m.Controls.SetInputState(input)
+		if err := m.RunFrame(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
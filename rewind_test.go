@@ -0,0 +1,93 @@
+package gomu8080
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// machine accumulating port 1 into RAM on every RST 2
+func testMachine(t *testing.T) *Machine {
+	t.Helper()
+	program, err := asm.Assemble(`
+	ORG 0
+	LXI SP,2400H
+	EI
+LOOP:	JMP LOOP
+	ORG 8
+	EI
+	RET
+	ORG 10H
+	PUSH PSW
+	PUSH B
+	IN 1
+	MOV B,A
+	LDA 2100H
+	ADD B
+	STA 2100H
+	POP B
+	POP PSW
+	EI
+	RET
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mmu := NewMMU()
+	p := NewProcessor(mmu, false)
+	binary := program.Binary()
+	mmu.Load(len(binary), binary, 0)
+	return NewMachine(p, mmu)
+}
+
+func TestRewindTo(t *testing.T) {
+	m := testMachine(t)
+	m.Rewind = NewRewind(4, 5)
+	checksums := map[int]uint32{}
+	for i := 0; i < 40; i++ {
+		checksums[m.FrameCount()] = m.Checksum()
+		m.Controls.Press(InputCoin, i%3 == 0)
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Rewind.OldestFrame() != 25 {
+		t.Fatalf("oldest frame %d, want 25", m.Rewind.OldestFrame())
+	}
+	if err := m.RewindTo(24); err != ErrRewindRange {
+		t.Fatalf("rewind before the buffer: %v", err)
+	}
+	for _, frame := range []int{38, 33, 27, 25} {
+		if err := m.RewindTo(frame); err != nil {
+			t.Fatal(err)
+		}
+		if m.FrameCount() != frame || m.Checksum() != checksums[frame] {
+			t.Errorf("rewind to %d: at frame %d, checksum differs", frame, m.FrameCount())
+		}
+	}
+}
+
+func TestRewindResetOnLoadState(t *testing.T) {
+	m := testMachine(t)
+	m.Rewind = NewRewind(4, 5)
+	var saved bytes.Buffer
+	if err := m.SaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		m.Controls.Press(InputCoin, i%2 == 0)
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.LoadState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rewind.OldestFrame() != -1 {
+		t.Errorf("rewind buffer kept across LoadState, oldest frame %d", m.Rewind.OldestFrame())
+	}
+	if err := m.RewindTo(m.FrameCount() - 5); err != ErrRewindRange {
+		t.Errorf("rewind into the replaced timeline: %v", err)
+	}
+}
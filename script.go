@@ -0,0 +1,114 @@
+package gomu8080
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// InputScript - inputs held during ranges of frames, one range per line
+//
+//	# frame[-last]  inputs
+//	60-64           coin
+//	120-124         p1_start
+//	300-420         p1_left,p1_fire
+//
+// frames count from 0, inputs are the names of the config file
+type InputScript struct {
+	ranges []scriptRange
+}
+
+type scriptRange struct {
+	first  int
+	last   int
+	inputs []Input
+}
+
+func ParseInputScript(r io.Reader) (*InputScript, error) {
+	s := &InputScript{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("InputScript: Error: line %d: expected frames and inputs", line)
+		}
+		r, err := parseFrameRange(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("InputScript: Error: line %d: %v", line, err)
+		}
+		for _, name := range strings.Split(fields[1], ",") {
+			input, err := ParseInput(name)
+			if err != nil {
+				return nil, fmt.Errorf("InputScript: Error: line %d: %v", line, err)
+			}
+			r.inputs = append(r.inputs, input)
+		}
+		s.ranges = append(s.ranges, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func LoadInputScript(path string) (*InputScript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseInputScript(f)
+}
+
+func parseFrameRange(text string) (scriptRange, error) {
+	r := scriptRange{}
+	first, last := text, text
+	if i := strings.IndexByte(text, '-'); i >= 0 {
+		first, last = text[:i], text[i+1:]
+	}
+	var err error
+	if r.first, err = strconv.Atoi(first); err != nil {
+		return r, fmt.Errorf("invalid frame %q", first)
+	}
+	if r.last, err = strconv.Atoi(last); err != nil {
+		return r, fmt.Errorf("invalid frame %q", last)
+	}
+	if r.first < 0 || r.last < r.first {
+		return r, fmt.Errorf("invalid frame range %q", text)
+	}
+	return r, nil
+}
+
+// Apply - press the inputs held at frame and release all others
+func (s *InputScript) Apply(c *Controls, frame int) {
+	c.ReleaseAll()
+	for _, r := range s.ranges {
+		if frame < r.first || frame > r.last {
+			continue
+		}
+		for _, input := range r.inputs {
+			c.Press(input, true)
+		}
+	}
+}
+
+// Frames - frames until the last input is released
+func (s *InputScript) Frames() int {
+	frames := 0
+	for _, r := range s.ranges {
+		if r.last+1 > frames {
+			frames = r.last + 1
+		}
+	}
+	return frames
+}
@@ -0,0 +1,25 @@
+package gomu8080
+
+// ShiftRegister - Midway 8080 hardware shifting 16-bit data for the sprites
+//
+//	port 2 (out) - shift amount (bits 0-2)
+//	port 4 (out) - data, shifted into the high byte while the old high byte moves to the low byte
+//	port 3 (in)  - 8 bits of the register starting at bit 15 - shift amount
+type ShiftRegister struct {
+	Value  uint16
+	Offset uint8
+}
+
+// shifted result
+func (s *ShiftRegister) In(port byte) byte {
+	return byte(s.Value >> (8 - s.Offset))
+}
+
+func (s *ShiftRegister) Out(port byte, data byte) {
+	switch port {
+	case 2:
+		s.Offset = data & 0x07
+	case 4:
+		s.Value = uint16(data)<<8 | s.Value>>8
+	}
+}
@@ -0,0 +1,70 @@
+package gomu8080
+
+import "testing"
+
+func TestShiftRegisterOffsets(t *testing.T) {
+	// register 0xCDAB = 1100 1101 1010 1011
+	tests := []struct {
+		offset byte
+		want   byte
+	}{
+		{0, 0xCD},
+		{1, 0x9B},
+		{2, 0x36},
+		{3, 0x6D},
+		{4, 0xDA},
+		{5, 0xB5},
+		{6, 0x6A},
+		{7, 0xD5},
+	}
+	for _, tt := range tests {
+		s := &ShiftRegister{}
+		s.Out(4, 0xAB)
+		s.Out(4, 0xCD)
+		s.Out(2, tt.offset)
+		if got := s.In(3); got != tt.want {
+			t.Errorf("offset %d: got %02X, want %02X", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestShiftRegisterWrites(t *testing.T) {
+	tests := []struct {
+		writes []byte
+		want   uint16
+	}{
+		{[]byte{0x12}, 0x1200},
+		{[]byte{0x12, 0x34}, 0x3412},
+		{[]byte{0x12, 0x34, 0x56}, 0x5634},
+		{[]byte{0xFF, 0x00, 0x00}, 0x0000},
+	}
+	for _, tt := range tests {
+		s := &ShiftRegister{}
+		for _, data := range tt.writes {
+			s.Out(4, data)
+		}
+		if s.Value != tt.want {
+			t.Errorf("writes %X: got %04X, want %04X", tt.writes, s.Value, tt.want)
+		}
+	}
+}
+
+func TestShiftRegisterOffsetMask(t *testing.T) {
+	tests := []struct {
+		data byte
+		want uint8
+	}{
+		{0x00, 0},
+		{0x07, 7},
+		{0x08, 0},
+		{0x0C, 4},
+		{0xFD, 5},
+	}
+	for _, tt := range tests {
+		s := &ShiftRegister{}
+		s.Out(2, tt.data)
+		if s.Offset != tt.want {
+			t.Errorf("port 2 %02X: got offset %d, want %d", tt.data, s.Offset, tt.want)
+		}
+	}
+}
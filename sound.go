@@ -0,0 +1,225 @@
+package gomu8080
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Sound - sound effect of Space Invaders
+type Sound int
+
+const (
+	SoundUFO Sound = iota
+	SoundShot
+	SoundPlayerDeath
+	SoundInvaderHit
+	SoundFleet1
+	SoundFleet2
+	SoundFleet3
+	SoundFleet4
+	SoundUFOHit
+	SoundExtendedPlay
+	SoundCount
+)
+
+var soundNames = [SoundCount]string{
+	"ufo", "shot", "player death", "invader hit",
+	"fleet 1", "fleet 2", "fleet 3", "fleet 4",
+	"ufo hit", "extended play",
+}
+
+func (s Sound) String() string {
+	if s < 0 || s >= SoundCount {
+		return fmt.Sprintf("sound %d", int(s))
+	}
+	return soundNames[s]
+}
+
+// SoundPlayer - output of the sound effects
+type SoundPlayer interface {
+	// Play - start sound, the UFO sound repeats until it is stopped
+	Play(sound Sound)
+	Stop(sound Sound)
+}
+
+// sound latch bits of output port 3 and 5
+var (
+	port3Sounds = []Sound{SoundUFO, SoundShot, SoundPlayerDeath, SoundInvaderHit, SoundExtendedPlay}
+	port5Sounds = []Sound{SoundFleet1, SoundFleet2, SoundFleet3, SoundFleet4, SoundUFOHit}
+)
+
+// sound amplifier enable, bit 5 of port 3
+const soundAmpEnable = 0x20
+
+// SoundBoard - external hardware latching sound bits on output port 3 and 5
+// a sound starts when its bit rises, the UFO sound loops while its bit
+// and the amplifier enable are set
+type SoundBoard struct {
+	// nil is silent
+	Player SoundPlayer
+
+	port3 byte
+	port5 byte
+}
+
+func NewSoundBoard(player SoundPlayer) *SoundBoard {
+	return &SoundBoard{Player: player}
+}
+
+func (s *SoundBoard) Out(port byte, data byte) {
+	var previous byte
+	var sounds []Sound
+	wasEnabled := s.port3&soundAmpEnable != 0
+	switch port {
+	case 3:
+		previous, s.port3 = s.port3, data
+		sounds = port3Sounds
+	case 5:
+		previous, s.port5 = s.port5, data
+		sounds = port5Sounds
+	default:
+		return
+	}
+	if s.Player == nil {
+		return
+	}
+
+	enabled := s.port3&soundAmpEnable != 0
+	rising := data &^ previous
+	for bit, sound := range sounds {
+		mask := byte(1) << bit
+		if sound == SoundUFO {
+			// looping while the bit is set and the amplifier is enabled
+			wasPlaying := wasEnabled && previous&mask != 0
+			playing := enabled && data&mask != 0
+			if playing && !wasPlaying {
+				s.Player.Play(sound)
+			}
+			if wasPlaying && !playing {
+				s.Player.Stop(sound)
+			}
+			continue
+		}
+		if rising&mask != 0 && enabled {
+			s.Player.Play(sound)
+		}
+	}
+}
+
+// UFO sound loops while bit 0 of port 3 and the amplifier are set
+func (s *SoundBoard) looping() bool {
+	return s.port3&soundAmpEnable != 0 && s.port3&1 != 0
+}
+
+// start or stop the UFO loop when it changed since wasLooping
+func (s *SoundBoard) syncLoop(wasLooping bool) {
+	if s.Player == nil || s.looping() == wasLooping {
+		return
+	}
+	if wasLooping {
+		s.Player.Stop(SoundUFO)
+	} else {
+		s.Player.Play(SoundUFO)
+	}
+}
+
+// replace the latches with those of a loaded state,
+// only the UFO loop follows, other sounds are not started
+func (s *SoundBoard) setLatches(port3 byte, port5 byte) {
+	wasLooping := s.looping()
+	s.port3, s.port5 = port3, port5
+	s.syncLoop(wasLooping)
+}
+
+// LogSoundPlayer - headless player writing sound events as text
+type LogSoundPlayer struct {
+	Out io.Writer
+}
+
+func (l *LogSoundPlayer) Play(sound Sound) {
+	fmt.Fprintf(l.Out, "sound: play %s\n", sound)
+}
+
+func (l *LogSoundPlayer) Stop(sound Sound) {
+	fmt.Fprintf(l.Out, "sound: stop %s\n", sound)
+}
+
+// SynthesizeSound - approximation of a sound effect for missing samples,
+// 16-bit signed little endian stereo PCM, the UFO sound loops seamlessly
+func SynthesizeSound(sound Sound, sampleRate int) []byte {
+	seconds := 0.0
+	var wave func(t float64) float64
+
+	// deterministic noise
+	seed := uint32(1)
+	noise := func() float64 {
+		seed = seed*1664525 + 1013904223
+		return float64(int32(seed)) / math.MaxInt32
+	}
+	square := func(t float64, frequency float64) float64 {
+		if math.Mod(t*frequency, 1) < 0.5 {
+			return 1
+		}
+		return -1
+	}
+	// phase of a frequency sweep from f0 to f1 over duration
+	sweep := func(t float64, f0 float64, f1 float64, duration float64) float64 {
+		return math.Sin(2 * math.Pi * (f0*t + (f1-f0)*t*t/(2*duration)))
+	}
+
+	switch sound {
+	case SoundUFO:
+		// 600-900 Hz warble, whole LFO cycles for the loop
+		seconds = 0.5
+		wave = func(t float64) float64 {
+			return 0.4 * math.Sin(2*math.Pi*(750*t-150/(2*math.Pi*8)*math.Cos(2*math.Pi*8*t)))
+		}
+	case SoundShot:
+		seconds = 0.35
+		wave = func(t float64) float64 {
+			return 0.3 * (sweep(t, 1500, 300, seconds) + 0.5*noise()) * (1 - t/seconds)
+		}
+	case SoundPlayerDeath:
+		seconds = 1.0
+		wave = func(t float64) float64 {
+			return 0.6 * noise() * math.Exp(-3*t)
+		}
+	case SoundInvaderHit:
+		seconds = 0.25
+		wave = func(t float64) float64 {
+			return 0.4 * (0.5*sweep(t, 800, 100, seconds) + noise()) * (1 - t/seconds)
+		}
+	case SoundFleet1, SoundFleet2, SoundFleet3, SoundFleet4:
+		// descending four note march
+		frequency := []float64{98, 87, 78, 73}[sound-SoundFleet1]
+		seconds = 0.1
+		wave = func(t float64) float64 {
+			return 0.4 * square(t, frequency) * (1 - t/seconds)
+		}
+	case SoundUFOHit:
+		seconds = 1.0
+		wave = func(t float64) float64 {
+			return 0.4 * square(t, 300+200*math.Sin(2*math.Pi*16*t)) * (1 - t/seconds)
+		}
+	case SoundExtendedPlay:
+		seconds = 1.0
+		wave = func(t float64) float64 {
+			return 0.3 * math.Sin(2*math.Pi*1200*t) * square(t, 8)
+		}
+	default:
+		return nil
+	}
+
+	samples := int(seconds * float64(sampleRate))
+	data := make([]byte, samples*4)
+	for i := 0; i < samples; i++ {
+		value := wave(float64(i) / float64(sampleRate))
+		value = math.Max(-1, math.Min(1, value))
+		sample := uint16(int16(value * math.MaxInt16))
+		binary.LittleEndian.PutUint16(data[i*4:], sample)
+		binary.LittleEndian.PutUint16(data[i*4+2:], sample)
+	}
+	return data
+}
@@ -0,0 +1,68 @@
+package gomu8080
+
+import (
+	"bytes"
+	"testing"
+)
+
+// port writes and the sound events logged for them
+type soundWrite struct {
+	port byte
+	data byte
+	want string
+}
+
+func testSoundWrites(t *testing.T, writes []soundWrite) {
+	t.Helper()
+	var out bytes.Buffer
+	board := NewSoundBoard(&LogSoundPlayer{Out: &out})
+	for _, w := range writes {
+		out.Reset()
+		board.Out(w.port, w.data)
+		if out.String() != w.want {
+			t.Errorf("port %d %02X: got %q, want %q", w.port, w.data, out.String(), w.want)
+		}
+	}
+}
+
+func TestSoundOneShotRisingEdge(t *testing.T) {
+	testSoundWrites(t, []soundWrite{
+		{3, 0x02, ""}, // amplifier off
+		{3, 0x20, ""},
+		{3, 0x22, "sound: play shot\n"},
+		{3, 0x22, ""}, // still set
+		{3, 0x26, "sound: play player death\n"},
+		{3, 0x20, ""}, // one-shots are not stopped
+		{3, 0x28, "sound: play invader hit\n"},
+		{3, 0x30, "sound: play extended play\n"},
+	})
+}
+
+func TestSoundUFOLoop(t *testing.T) {
+	testSoundWrites(t, []soundWrite{
+		{3, 0x21, "sound: play ufo\n"},
+		{3, 0x21, ""},
+		{3, 0x20, "sound: stop ufo\n"},
+		{3, 0x21, "sound: play ufo\n"},
+		{3, 0x01, "sound: stop ufo\n"}, // amplifier disabled
+		{3, 0x01, ""},
+		{3, 0x21, "sound: play ufo\n"}, // amplifier enabled again
+		{3, 0x20, "sound: stop ufo\n"},
+		{3, 0x01, ""},
+	})
+}
+
+func TestSoundPort5(t *testing.T) {
+	testSoundWrites(t, []soundWrite{
+		{5, 0x01, ""}, // amplifier off
+		{3, 0x20, ""},
+		{5, 0x00, ""},
+		{5, 0x01, "sound: play fleet 1\n"},
+		{5, 0x02, "sound: play fleet 2\n"},
+		{5, 0x04, "sound: play fleet 3\n"},
+		{5, 0x08, "sound: play fleet 4\n"},
+		{5, 0x18, "sound: play ufo hit\n"},
+		{5, 0x18, ""},
+		{5, 0x00, ""},
+	})
+}
@@ -0,0 +1,237 @@
+package gomu8080
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// save state format (little endian)
+//
+//	header    - magic "G80S", version uint16
+//	processor - processorState
+//	memory    - 64K MMU memory
+//	machine   - machineState
+const (
+	stateMagic   = "G80S"
+	stateVersion = 2
+)
+
+var (
+	ErrStateMagic   = errors.New("State: Load: Error: not a save state")
+	ErrStateVersion = errors.New("State: Load: Error: unsupported save state version")
+)
+
+type stateHeader struct {
+	Magic   [4]byte
+	Version uint16
+}
+
+// processor registers, flags and interrupt state
+type processorState struct {
+	A, F, B, C, D, E, H, L byte
+	SP                     uint16
+	PC                     uint16
+
+	// flags byte including unused bits 1, 3 and 5
+	Flags byte
+
+	IsInteruptsEnabled bool
+	IsHalt             bool
+	EIDelay            bool
+	InterruptPending   bool
+	InterruptOpcode    byte
+
+	Cycles uint64
+}
+
+// external hardware of Space Invaders and the frame timing
+type machineState struct {
+	ShiftValue  uint16
+	ShiftOffset uint8
+
+	Dip3, Dip4, Dip5, Dip6, Dip7 bool
+
+	SoundPort3 byte
+	SoundPort5 byte
+
+	FrameCycles int64
+	FrameCount  int64
+}
+
+func writeStateHeader(w io.Writer) error {
+	header := stateHeader{Version: stateVersion}
+	copy(header.Magic[:], stateMagic)
+	return binary.Write(w, binary.LittleEndian, header)
+}
+
+func readStateHeader(r io.Reader) error {
+	header := stateHeader{}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if string(header.Magic[:]) != stateMagic {
+		return ErrStateMagic
+	}
+	if header.Version != stateVersion {
+		return ErrStateVersion
+	}
+	return nil
+}
+
+// SaveState - write processor section of save state
+func (p *Processor) SaveState(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, p.state())
+}
+
+// LoadState - read processor section of save state
+func (p *Processor) LoadState(r io.Reader) error {
+	state := processorState{}
+	if err := binary.Read(r, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+	p.setState(state)
+	return nil
+}
+
+func (p *Processor) state() processorState {
+	return processorState{
+		A: p.A, F: p.F, B: p.B, C: p.C, D: p.D, E: p.E, H: p.H, L: p.L,
+		SP:                 p.SP,
+		PC:                 p.PC,
+		Flags:              p.getFlags(),
+		IsInteruptsEnabled: p.IsInteruptsEnabled,
+		IsHalt:             p.IsHalt,
+		EIDelay:            p.eiDelay,
+		InterruptPending:   p.interruptPending,
+		InterruptOpcode:    p.interruptOpcode,
+		Cycles:             p.Cycles,
+	}
+}
+
+// replace the processor state, the undo log of the old state is dropped
+func (p *Processor) setState(s processorState) {
+	p.restoreState(s)
+	p.ClearUndo()
+}
+
+func (p *Processor) restoreState(s processorState) {
+	p.A, p.F, p.B, p.C, p.D, p.E, p.H, p.L = s.A, s.F, s.B, s.C, s.D, s.E, s.H, s.L
+	p.SP = s.SP
+	p.PC = s.PC
+	p.setFlags(s.Flags)
+	p.IsInteruptsEnabled = s.IsInteruptsEnabled
+	p.IsHalt = s.IsHalt
+	p.eiDelay = s.EIDelay
+	p.interruptPending = s.InterruptPending
+	p.interruptOpcode = s.InterruptOpcode
+	p.Cycles = s.Cycles
+}
+
+// SaveState - write memory section of save state
+// the memory map is part of the machine setup and is not saved
+func (m *MMU) SaveState(w io.Writer) error {
+	_, err := w.Write(m.Memory[:])
+	return err
+}
+
+// LoadState - read memory section of save state
+func (m *MMU) LoadState(r io.Reader) error {
+	_, err := io.ReadFull(r, m.Memory[:])
+	return err
+}
+
+// SaveState - write the whole machine (processor, memory and external hardware)
+func (m *Machine) SaveState(w io.Writer) error {
+	if err := writeStateHeader(w); err != nil {
+		return err
+	}
+	if err := m.processor.SaveState(w); err != nil {
+		return err
+	}
+	if err := m.mmu.SaveState(w); err != nil {
+		return err
+	}
+	c := m.Controls
+	state := machineState{
+		ShiftValue:  m.ShiftRegister.Value,
+		ShiftOffset: m.ShiftRegister.Offset,
+		Dip3:        c.dip3, Dip4: c.dip4, Dip5: c.dip5, Dip6: c.dip6, Dip7: c.dip7,
+		SoundPort3:  m.SoundBoard.port3,
+		SoundPort5:  m.SoundBoard.port5,
+		FrameCycles: int64(m.frameCycles),
+		FrameCount:  int64(m.frameCount),
+	}
+	return binary.Write(w, binary.LittleEndian, state)
+}
+
+// LoadState - restore the whole machine, nothing is changed when the state is invalid
+// the rewind buffer belongs to the replaced timeline and is dropped
+func (m *Machine) LoadState(r io.Reader) error {
+	if err := m.loadState(r); err != nil {
+		return err
+	}
+	if m.Rewind != nil {
+		m.Rewind.Reset()
+	}
+	return nil
+}
+
+func (m *Machine) loadState(r io.Reader) error {
+	if err := readStateHeader(r); err != nil {
+		return err
+	}
+	processor := processorState{}
+	if err := binary.Read(r, binary.LittleEndian, &processor); err != nil {
+		return err
+	}
+	var memory [65536]byte
+	if _, err := io.ReadFull(r, memory[:]); err != nil {
+		return err
+	}
+	state := machineState{}
+	if err := binary.Read(r, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	m.processor.setState(processor)
+	m.mmu.Memory = memory
+	m.ShiftRegister.Value = state.ShiftValue
+	m.ShiftRegister.Offset = state.ShiftOffset
+	c := m.Controls
+	c.dip3, c.dip4, c.dip5, c.dip6, c.dip7 = state.Dip3, state.Dip4, state.Dip5, state.Dip6, state.Dip7
+	m.SoundBoard.setLatches(state.SoundPort3, state.SoundPort5)
+	m.frameCycles = int(state.FrameCycles)
+	m.frameCount = int(state.FrameCount)
+	return nil
+}
+
+// SaveStateFile - save the whole machine to file
+func (m *Machine) SaveStateFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := m.SaveState(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadStateFile - restore the whole machine from file
+func (m *Machine) LoadStateFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.LoadState(bufio.NewReader(f))
+}
@@ -0,0 +1,66 @@
+package gomu8080
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// sound player recording play and stop events
+type eventPlayer struct {
+	events []string
+}
+
+func (e *eventPlayer) Play(sound Sound) {
+	e.events = append(e.events, "play "+sound.String())
+}
+
+func (e *eventPlayer) Stop(sound Sound) {
+	e.events = append(e.events, "stop "+sound.String())
+}
+
+func TestStateSoundAndFrames(t *testing.T) {
+	m := testMachine(t)
+	for i := 0; i < 7; i++ {
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.SoundBoard.Out(3, soundAmpEnable|0x01)
+	m.SoundBoard.Out(5, 0x04)
+	frameCycles := m.frameCycles
+	var saved bytes.Buffer
+	if err := m.SaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	m.SoundBoard.Out(3, 0)
+	m.SoundBoard.Out(5, 0)
+	for i := 0; i < 3; i++ {
+		if err := m.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	player := &eventPlayer{}
+	m.SoundBoard.Player = player
+	if err := m.LoadState(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if m.FrameCount() != 7 || m.frameCycles != frameCycles {
+		t.Errorf("frame %d cycles %d, want 7 %d", m.FrameCount(), m.frameCycles, frameCycles)
+	}
+	if m.SoundBoard.port3 != soundAmpEnable|0x01 || m.SoundBoard.port5 != 0x04 {
+		t.Errorf("sound latches %02X %02X", m.SoundBoard.port3, m.SoundBoard.port5)
+	}
+	// only the UFO loop resumes, the latched fleet sound is not replayed
+	if len(player.events) != 1 || player.events[0] != "play ufo" {
+		t.Errorf("events %v, want [play ufo]", player.events)
+	}
+
+	// version 1 states lack the sound latches and frame timing
+	old := append([]byte(nil), saved.Bytes()...)
+	binary.LittleEndian.PutUint16(old[4:], 1)
+	if err := m.LoadState(bytes.NewReader(old)); err != ErrStateVersion {
+		t.Errorf("version 1 state: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package gomu8080
+
+// undo log of executed instructions for reverse execution, a ring buffer
+// of the processor state before each Run and the memory bytes it overwrote
+// memory written by hooks or devices (e.g. DMA) and device side effects are not undone,
+// idle ticks of a halted processor are not logged
+type undoLog struct {
+	records []undoRecord
+	start   int
+	count   int
+
+	// record of the running instruction
+	current *undoRecord
+}
+
+type undoRecord struct {
+	state  processorState
+	writes []undoWrite
+}
+
+type undoWrite struct {
+	address uint16
+	old     byte
+}
+
+// EnableUndo - log the last limit instructions to step back, 0 disables the log
+func (p *Processor) EnableUndo(limit int) {
+	if limit <= 0 {
+		p.undo = nil
+		return
+	}
+	p.undo = &undoLog{records: make([]undoRecord, limit)}
+}
+
+// UndoDepth - instructions that can be stepped back
+func (p *Processor) UndoDepth() int {
+	if p.undo == nil {
+		return 0
+	}
+	return p.undo.count
+}
+
+// ClearUndo - drop the logged instructions, called when registers or memory
+// are replaced or edited outside of the executed instructions
+func (p *Processor) ClearUndo() {
+	if p.undo != nil {
+		p.undo.count = 0
+		p.undo.current = nil
+	}
+}
+
+// start logging the instruction about to run, the oldest record is dropped when the log is full
+func (u *undoLog) begin(p *Processor) {
+	if u.count == len(u.records) {
+		u.start = (u.start + 1) % len(u.records)
+		u.count--
+	}
+	record := &u.records[(u.start+u.count)%len(u.records)]
+	record.state = p.state()
+	record.writes = record.writes[:0]
+	u.current = record
+	u.count++
+}
+
+// log the byte at address before it is overwritten
+func (u *undoLog) write(p *Processor, address uint16) {
+	if u.current != nil {
+		u.current.writes = append(u.current.writes, undoWrite{address, p.memory.Read(address)})
+	}
+}
+
+// StepBack - undo the last executed instruction, false when the log is empty
+// write and execute watchpoints see the undone writes and the instruction stepped back to
+func (p *Processor) StepBack() bool {
+	u := p.undo
+	if u == nil || u.count == 0 {
+		return false
+	}
+	u.count--
+	record := &u.records[(u.start+u.count)%len(u.records)]
+	p.instPC = record.state.PC
+	for i := len(record.writes) - 1; i >= 0; i-- {
+		address := record.writes[i].address
+		if len(p.watchpoints) == 0 {
+			p.memory.Write(address, record.writes[i].old)
+			continue
+		}
+		// undone writes are reported as writes of the old value
+		current := p.memory.Read(address)
+		p.memory.Write(address, record.writes[i].old)
+		p.watch(WatchWrite, address, current, record.writes[i].old)
+	}
+	p.restoreState(record.state)
+	u.current = nil
+	if len(p.watchpoints) > 0 && !p.IsHalt {
+		opcode := p.memory.Read(p.PC)
+		p.watch(WatchExecute, p.PC, opcode, opcode)
+	}
+	return true
+}
+
+// RunBack - step back until stop returns true (checked after each step)
+// or the log is empty, returns the number of instructions undone
+func (p *Processor) RunBack(stop func(p *Processor) bool) int {
+	steps := 0
+	for p.StepBack() {
+		steps++
+		if stop != nil && stop(p) {
+			break
+		}
+	}
+	return steps
+}
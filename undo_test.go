@@ -0,0 +1,123 @@
+package gomu8080
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/detohm/gomu8080/asm"
+)
+
+// processor running a loop which stores a counter, with the undo log enabled
+func undoProcessor(t *testing.T) (*Processor, *MMU) {
+	t.Helper()
+	program, err := asm.Assemble(`
+	ORG 0
+	LXI SP,2400H
+	MVI A,5
+LOOP:	STA 2100H
+	PUSH PSW
+	POP PSW
+	DCR A
+	JNZ LOOP
+	HLT
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mmu := NewMMU()
+	p := NewProcessor(mmu, false)
+	binary := program.Binary()
+	mmu.Load(len(binary), binary, 0)
+	p.EnableUndo(1000)
+	return p, mmu
+}
+
+func TestStepBack(t *testing.T) {
+	p, mmu := undoProcessor(t)
+	var states []processorState
+	var memories [][65536]byte
+	for !p.IsHalt {
+		states = append(states, p.state())
+		memories = append(memories, mmu.Memory)
+		p.Run()
+	}
+	if p.UndoDepth() != len(states) {
+		t.Fatalf("depth %d, want %d", p.UndoDepth(), len(states))
+	}
+	for i := len(states) - 1; i >= 0; i-- {
+		if !p.StepBack() {
+			t.Fatalf("step back %d failed", i)
+		}
+		if p.state() != states[i] || mmu.Memory != memories[i] {
+			t.Fatalf("step back %d: state differs", i)
+		}
+	}
+	if p.StepBack() {
+		t.Error("stepped back with an empty log")
+	}
+}
+
+func TestUndoLimit(t *testing.T) {
+	p, _ := undoProcessor(t)
+	p.EnableUndo(3)
+	for i := 0; i < 10; i++ {
+		p.Run()
+	}
+	if p.UndoDepth() != 3 || p.RunBack(nil) != 3 {
+		t.Error("log is not limited to 3 instructions")
+	}
+}
+
+func TestUndoSkipsHalt(t *testing.T) {
+	p, mmu := undoProcessor(t)
+	for !p.IsHalt {
+		p.Run()
+	}
+	depth := p.UndoDepth()
+	for i := 0; i < 100; i++ {
+		p.Run()
+	}
+	if p.UndoDepth() != depth {
+		t.Errorf("depth %d after halt ticks, want %d", p.UndoDepth(), depth)
+	}
+	// one step back returns to the HLT
+	if !p.StepBack() || p.IsHalt || mmu.Memory[p.PC] != 0x76 {
+		t.Errorf("step back from halt: PC %04X halted %v", p.PC, p.IsHalt)
+	}
+}
+
+func TestUndoClearedOnLoadState(t *testing.T) {
+	p, mmu := undoProcessor(t)
+	m := &Machine{processor: p, mmu: mmu, ShiftRegister: &ShiftRegister{}, Controls: NewControls(), SoundBoard: NewSoundBoard(nil)}
+	var saved bytes.Buffer
+	for i := 0; i < 5; i++ {
+		p.Run()
+	}
+	if err := m.SaveState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		p.Run()
+	}
+	if err := m.LoadState(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if p.UndoDepth() != 0 || p.StepBack() {
+		t.Error("undo log kept across LoadState")
+	}
+}
+
+func TestUndoClearedByDebugger(t *testing.T) {
+	commands := []string{"set A 1", "w 2100 1"}
+	for _, command := range commands {
+		p, _ := undoProcessor(t)
+		p.Run()
+		var out bytes.Buffer
+		d := NewDebugger(p, strings.NewReader(command+"\n"), &out)
+		d.command(strings.Fields(command))
+		if p.UndoDepth() != 0 {
+			t.Errorf("%s: undo log kept", command)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gomu8080
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// upright screen of Space Invaders, the monitor is rotated by 90 degrees
+const (
+	ScreenWidth  = 224
+	ScreenHeight = 256
+
+	// 1 bit per pixel, 32 bytes per monitor line (a column of the upright screen)
+	VideoMemoryStart = 0x2400
+	VideoMemorySize  = ScreenWidth * ScreenHeight / 8
+)
+
+// OverlayBand - coloured strip of the overlay on the upright screen,
+// rows [Top, Bottom) and columns [Left, Right), Right 0 means the full width
+type OverlayBand struct {
+	Top, Bottom int
+	Left, Right int
+	Color       color.RGBA
+}
+
+// Overlay - colour of lit pixels, Base outside the bands
+type Overlay struct {
+	Base  color.RGBA
+	Bands []OverlayBand
+}
+
+var (
+	white = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	red   = color.RGBA{0xFF, 0x30, 0x30, 0xFF}
+	green = color.RGBA{0x30, 0xFF, 0x30, 0xFF}
+)
+
+// OverlayPresets - overlays selectable by name
+var OverlayPresets = map[string]Overlay{
+	// black and white monitor
+	"none": {Base: white},
+	// cellophane of the upright cabinet - red UFO band, green player and shield band
+	"classic": {Base: white, Bands: []OverlayBand{
+		{Top: 32, Bottom: 64, Color: red},
+		{Top: 184, Bottom: 240, Color: green},
+		{Top: 240, Bottom: 256, Left: 16, Right: 134, Color: green},
+	}},
+	"green": {Base: color.RGBA{0x33, 0xFF, 0x66, 0xFF}},
+	"amber": {Base: color.RGBA{0xFF, 0xB0, 0x00, 0xFF}},
+}
+
+// OverlayNames - names of the presets, sorted
+func OverlayNames() []string {
+	var names []string
+	for name := range OverlayPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VideoOptions - post processing of the rendered screen
+type VideoOptions struct {
+	Overlay Overlay
+
+	// pixels drawn as Scale x Scale blocks (1 when 0)
+	Scale int
+
+	// darken the last output line of every screen row
+	Scanlines bool
+
+	// fraction of the brightness kept from the previous frame (0 - 1),
+	// fading trails like the phosphor of the monitor
+	Persistence float64
+}
+
+// Video - renders video memory into an upright RGBA frame
+type Video struct {
+	options VideoOptions
+	frame   *image.RGBA
+
+	// colour of every screen pixel from the overlay
+	colors []color.RGBA
+
+	// brightness of every screen pixel (0 - 1)
+	glow []float64
+}
+
+func NewVideo(options VideoOptions) *Video {
+	if options.Scale < 1 {
+		options.Scale = 1
+	}
+	v := &Video{}
+	v.options = options
+	v.frame = image.NewRGBA(image.Rect(0, 0, ScreenWidth*options.Scale, ScreenHeight*options.Scale))
+	v.glow = make([]float64, ScreenWidth*ScreenHeight)
+
+	v.colors = make([]color.RGBA, ScreenWidth*ScreenHeight)
+	for y := 0; y < ScreenHeight; y++ {
+		for x := 0; x < ScreenWidth; x++ {
+			v.colors[y*ScreenWidth+x] = options.Overlay.colorAt(x, y)
+		}
+	}
+	return v
+}
+
+func (o Overlay) colorAt(x int, y int) color.RGBA {
+	c := o.Base
+	for _, band := range o.Bands {
+		right := band.Right
+		if right == 0 {
+			right = ScreenWidth
+		}
+		if y >= band.Top && y < band.Bottom && x >= band.Left && x < right {
+			c = band.Color
+		}
+	}
+	return c
+}
+
+// Bounds - size of the rendered frame
+func (v *Video) Bounds() image.Rectangle {
+	return v.frame.Bounds()
+}
+
+// Render - render video memory (VideoMemorySize bytes) into the frame
+func (v *Video) Render(vram []byte) *image.RGBA {
+	persistence := v.options.Persistence
+	for i := 0; i < VideoMemorySize && i < len(vram); i++ {
+		value := vram[i]
+		x := i / 32
+		for bit := 0; bit < 8; bit++ {
+			y := ScreenHeight - 1 - ((i%32)*8 + bit)
+			pixel := y*ScreenWidth + x
+
+			glow := v.glow[pixel] * persistence
+			if (value>>uint32(bit))&0x01 > 0 {
+				glow = 1
+			}
+			v.glow[pixel] = glow
+			v.plot(x, y, v.colors[pixel], glow)
+		}
+	}
+	return v.frame
+}
+
+// draw screen pixel as a block of the output frame
+func (v *Video) plot(x int, y int, c color.RGBA, glow float64) {
+	scale := v.options.Scale
+	for dy := 0; dy < scale; dy++ {
+		brightness := glow
+		if v.options.Scanlines && dy == scale-1 && (scale > 1 || y%2 == 1) {
+			brightness *= 0.5
+		}
+		r := uint8(float64(c.R) * brightness)
+		g := uint8(float64(c.G) * brightness)
+		b := uint8(float64(c.B) * brightness)
+		offset := v.frame.PixOffset(x*scale, y*scale+dy)
+		for dx := 0; dx < scale; dx++ {
+			v.frame.Pix[offset] = r
+			v.frame.Pix[offset+1] = g
+			v.frame.Pix[offset+2] = b
+			v.frame.Pix[offset+3] = 0xFF
+			offset += 4
+		}
+	}
+}
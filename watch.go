@@ -0,0 +1,108 @@
+package gomu8080
+
+import "strings"
+
+// WatchKind - memory accesses that trigger a watchpoint, combined with |
+type WatchKind int
+
+const (
+	WatchRead    WatchKind = 1 << iota // data and operand reads
+	WatchWrite                         // writes, including writes ignored by ROM
+	WatchExecute                       // opcode fetch of an instruction
+)
+
+// String - access letters, e.g. "rw"
+func (k WatchKind) String() string {
+	var b strings.Builder
+	for i, letter := range "rwx" {
+		if k&(1<<i) != 0 {
+			b.WriteRune(letter)
+		}
+	}
+	return b.String()
+}
+
+// ParseWatchKind - parse access letters r, w and x, e.g. "rw"
+func ParseWatchKind(text string) (WatchKind, bool) {
+	kind := WatchKind(0)
+	for _, letter := range strings.ToLower(text) {
+		switch letter {
+		case 'r':
+			kind |= WatchRead
+		case 'w':
+			kind |= WatchWrite
+		case 'x':
+			kind |= WatchExecute
+		default:
+			return 0, false
+		}
+	}
+	return kind, kind != 0
+}
+
+// Watchpoint - address range [Start, End] watched for accesses of Kind
+type Watchpoint struct {
+	Start uint16
+	End   uint16
+	Kind  WatchKind
+}
+
+// WatchHit - access which triggered a watchpoint
+type WatchHit struct {
+	Kind    WatchKind
+	Address uint16
+
+	// address of the instruction making the access
+	PC uint16
+
+	// byte before the access and the byte written, equal for read and execute,
+	// a write ignored by ROM reports the attempted byte while memory keeps Old
+	Old byte
+	New byte
+}
+
+// WatchFunc - callback on a watchpoint hit, called while the instruction executes
+type WatchFunc func(p *Processor, hit WatchHit)
+
+type watchpoint struct {
+	Watchpoint
+	fn WatchFunc
+}
+
+// Watch - call fn on accesses of kind to [start, end],
+// nil fn removes the watchpoints of the range added with the same kind,
+// or of any kind when kind is 0
+func (p *Processor) Watch(start uint16, end uint16, kind WatchKind, fn WatchFunc) {
+	if start > end {
+		start, end = end, start
+	}
+	if fn == nil {
+		kept := p.watchpoints[:0]
+		for _, w := range p.watchpoints {
+			if w.Start != start || w.End != end || (kind != 0 && w.Kind != kind) {
+				kept = append(kept, w)
+			}
+		}
+		p.watchpoints = kept
+		return
+	}
+	p.watchpoints = append(p.watchpoints, watchpoint{Watchpoint{start, end, kind}, fn})
+}
+
+// Watchpoints - watched ranges in the order they were added
+func (p *Processor) Watchpoints() []Watchpoint {
+	list := make([]Watchpoint, len(p.watchpoints))
+	for i, w := range p.watchpoints {
+		list[i] = w.Watchpoint
+	}
+	return list
+}
+
+// report access to the watchpoints covering address
+func (p *Processor) watch(kind WatchKind, address uint16, old byte, new byte) {
+	for _, w := range p.watchpoints {
+		if w.Kind&kind != 0 && address >= w.Start && address <= w.End {
+			w.fn(p, WatchHit{Kind: kind, Address: address, PC: p.instPC, Old: old, New: new})
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package gomu8080
+
+import "testing"
+
+func TestWatchRemoveKind(t *testing.T) {
+	p := NewProcessor(NewMMU(), false)
+	noop := func(p *Processor, hit WatchHit) {}
+	add := func() {
+		p.watchpoints = nil
+		p.Watch(0x2000, 0x20FF, WatchRead, noop)
+		p.Watch(0x2000, 0x20FF, WatchWrite, noop)
+		p.Watch(0x2000, 0x20FF, WatchRead|WatchWrite, noop)
+		p.Watch(0x2100, 0x21FF, WatchWrite, noop)
+	}
+	tests := []struct {
+		start uint16
+		end   uint16
+		kind  WatchKind
+		kept  []Watchpoint
+	}{
+		{0x2000, 0x20FF, WatchWrite, []Watchpoint{
+			{0x2000, 0x20FF, WatchRead}, {0x2000, 0x20FF, WatchRead | WatchWrite}, {0x2100, 0x21FF, WatchWrite}}},
+		{0x20FF, 0x2000, WatchRead | WatchWrite, []Watchpoint{
+			{0x2000, 0x20FF, WatchRead}, {0x2000, 0x20FF, WatchWrite}, {0x2100, 0x21FF, WatchWrite}}},
+		{0x2000, 0x20FF, WatchExecute, []Watchpoint{
+			{0x2000, 0x20FF, WatchRead}, {0x2000, 0x20FF, WatchWrite}, {0x2000, 0x20FF, WatchRead | WatchWrite}, {0x2100, 0x21FF, WatchWrite}}},
+		{0x2000, 0x20FF, 0, []Watchpoint{{0x2100, 0x21FF, WatchWrite}}},
+	}
+	for _, tt := range tests {
+		add()
+		p.Watch(tt.start, tt.end, tt.kind, nil)
+		kept := p.Watchpoints()
+		if len(kept) != len(tt.kept) {
+			t.Errorf("remove %04X-%04X %s: kept %v, want %v", tt.start, tt.end, tt.kind, kept, tt.kept)
+			continue
+		}
+		for i := range kept {
+			if kept[i] != tt.kept[i] {
+				t.Errorf("remove %04X-%04X %s: kept %v, want %v", tt.start, tt.end, tt.kind, kept, tt.kept)
+				break
+			}
+		}
+	}
+}
+
+func TestWatchROMWrite(t *testing.T) {
+	mmu := NewMMU()
+	mmu.Load(3, []byte{0x3E, 0x55, 0x32}, 0x0000) // MVI A,55H ; STA 0010H
+	mmu.Load(2, []byte{0x10, 0x00}, 0x0003)
+	mmu.Memory[0x0010] = 0xAA
+	mmu.MapROM(0x0000, 0x1FFF)
+	p := NewProcessor(mmu, false)
+	var hits []WatchHit
+	p.Watch(0x0010, 0x0010, WatchWrite, func(p *Processor, hit WatchHit) {
+		hits = append(hits, hit)
+	})
+	p.Run()
+	p.Run()
+	want := WatchHit{Kind: WatchWrite, Address: 0x0010, PC: 0x0002, Old: 0xAA, New: 0x55}
+	if len(hits) != 1 || hits[0] != want {
+		t.Errorf("hits %+v, want %+v", hits, want)
+	}
+	if mmu.Memory[0x0010] != 0xAA {
+		t.Error("ROM was written")
+	}
+}